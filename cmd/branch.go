@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	branchHandler = handler.NewBranchHandler()
+	branchPattern string
+)
+
+// branchCmd represents the branch command
+var branchCmd = &cobra.Command{
+	Use:   "branch [task description]",
+	Short: "Create and switch to a branch named by AI",
+	Long: `Create and switch to a new branch whose name is generated from a task
+description or, without one, from the uncommitted changes (which are carried
+over to the new branch).
+
+The name follows branch.pattern or --pattern (default "{type}/{issue}-{slug}").
+{type} is a Conventional Commit type, {issue} comes from --issue and {slug} is
+a short hyphenated summary; placeholders left empty are dropped with their
+separator. Names are checked with git check-ref-format.
+
+Examples:
+  opencommit branch "add rate limiting to the login endpoint" --issue ABC-123
+  opencommit branch                      # name the branch after the current changes
+  opencommit branch "fix typo in docs" --dry-run`,
+	Run: branchHandler.BranchCommand(
+		context.Background(),
+		&issue,
+		&branchPattern,
+		&model,
+		&customBaseUrl,
+		&maxDiffLines,
+		&noConfirm,
+		&quiet,
+		&dryRun,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(branchCmd)
+
+	branchCmd.Flags().
+		StringVarP(&issue, "issue", "i", "", "issue key for the {issue} placeholder, e.g. ABC-123")
+	branchCmd.Flags().
+		StringVarP(&branchPattern, "pattern", "", "", "branch name pattern (default: branch.pattern or "+service.DefaultBranchPattern+")")
+	branchCmd.Flags().
+		BoolVarP(&noConfirm, "yes", "y", noConfirm, "skip confirmation prompt")
+	branchCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output (only works with --yes)")
+	branchCmd.Flags().
+		BoolVarP(&dryRun, "dry-run", "", dryRun, "print the branch name without creating it")
+	branchCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	branchCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	branchCmd.Flags().
+		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	changelogHandler = handler.NewChangelogHandler()
+	changelogFrom    string
+	changelogTo      = "HEAD"
+	changelogVersion string
+	changelogFormat  = service.ChangelogFormatMarkdown
+	changelogFile    string
+	changelogAll     = false
+	changelogPolish  = false
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Build a changelog from Conventional Commit history",
+	Long: `Group the Conventional Commits in --from..--to into a Keep a Changelog
+release section: breaking changes first, then Added (feat), Fixed (fix),
+Changed (perf, refactor) and Removed (revert). Other types and commits that
+are not Conventional Commits are left out unless --all is given.
+
+--from defaults to the latest tag before --to. The release is named after
+--version, a tag pointing at --to, or "Unreleased".
+
+With --file the section is prepended to an existing changelog; a section for
+the same version is replaced, so re-running the command is safe.
+
+Examples:
+  opencommit changelog
+  opencommit changelog --from v1.2.0 --to v1.3.0 --file CHANGELOG.md
+  opencommit changelog --format json
+  opencommit changelog --polish --version 1.3.0 --file CHANGELOG.md`,
+	Args: cobra.NoArgs,
+	Run: changelogHandler.ChangelogCommand(
+		context.Background(),
+		&changelogFrom,
+		&changelogTo,
+		&changelogVersion,
+		&changelogFormat,
+		&changelogFile,
+		&changelogAll,
+		&changelogPolish,
+		&model,
+		&language,
+		&customBaseUrl,
+		&quiet,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().
+		StringVarP(&changelogFrom, "from", "", "", "start of the range, exclusive (default: latest tag before --to)")
+	changelogCmd.Flags().
+		StringVarP(&changelogTo, "to", "", changelogTo, "end of the range")
+	changelogCmd.Flags().
+		StringVarP(&changelogVersion, "version", "", "", "release name (default: tag at --to, or Unreleased)")
+	changelogCmd.Flags().
+		StringVarP(&changelogFormat, "format", "", changelogFormat, "output format: markdown or json")
+	changelogCmd.Flags().
+		StringVarP(&changelogFile, "file", "f", "", "prepend the release to this changelog file")
+	changelogCmd.Flags().
+		BoolVarP(&changelogAll, "all", "", changelogAll, "include every commit, grouping the rest under Other")
+	changelogCmd.Flags().
+		BoolVarP(&changelogPolish, "polish", "", changelogPolish, "rewrite the entries as release notes with AI")
+	changelogCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output")
+	changelogCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	changelogCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	changelogCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the release notes")
+}
@@ -21,17 +21,29 @@ var getCmd = &cobra.Command{
   api.key             - AI provider API key
   api.model           - AI provider model name
   api.baseurl         - Custom base URL for AI provider API
-  api.last_success    - Provider used last successfully: 1 or 2
+  api.type            - Provider protocol: openai or anthropic (default: openai)
+  api.order           - Provider order: static, last_success or round_robin
+  api.last_provider   - Name of the provider used last successfully
 
 [api2] (optional secondary provider — used when api1 fails)
   api2.key            - Secondary AI provider API key
   api2.model          - Secondary AI provider model name
   api2.baseurl        - Secondary custom base URL for AI provider API
+  api2.type           - Secondary provider protocol: openai or anthropic
+
+[[providers]] (optional provider chain — replaces [api]/[api2] when present)
+  providers.<name>.type    - Provider protocol: openai or anthropic
+  providers.<name>.key     - API key
+  providers.<name>.baseurl - Base URL for the provider API
+  providers.<name>.model   - Model name
 
 [commit]
   commit.language     - Language for commit messages
   commit.max_length     - Maximum length of commit message
   commit.max_diff_lines - Truncate per-file diff to N lines to save tokens
+  commit.summarize      - Summarize each file with AI when the diff exceeds the token budget
+  commit.token_budget   - Approximate token budget for the diff sent to the model
+  commit.summary_workers - Concurrent per-file summary requests
 
 [behavior]
   behavior.stage_all   - Stage all changes in tracked files
@@ -42,22 +54,76 @@ var getCmd = &cobra.Command{
   behavior.dry_run     - Run without making changes
   behavior.show_diff   - Show diff before committing
   behavior.no_verify   - Skip git commit-msg hook verification
+  behavior.no_stream   - Show a spinner instead of streaming output
+  behavior.output      - Output format: text or json
+
+[lint]
+  lint.enabled              - Validate and repair generated messages
+  lint.types                - Allowed commit types
+  lint.scopes               - Allowed scopes
+  lint.subject_case         - Description case: lower or any
+  lint.subject_full_stop    - Forbid a trailing period in the description
+  lint.header_max_length    - Maximum header length
+  lint.body_leading_blank   - Require a blank line before the body
+  lint.body_max_line_length - Maximum body line length
+  lint.max_retries          - Re-prompts when violations remain after repair
+
+[style]
+  style.enabled       - Add style guidance learned from history to the prompt
+  style.sample_size   - Number of recent commits to learn from
+  style.branch        - Branch or revision to sample
+
+[secret]
+  secret.policy       - redact, warn, abort or off
+  secret.rules        - Extra secret rules
+  secret.allowlist    - Values or paths ignored by the secret scanner
+
+[ignore]
+  ignore.patterns     - Patterns for files left out of the prompt
+
+[release]
+  release.tag_prefix  - Prefix for release tags
+
+[branch]
+  branch.pattern      - Name pattern for opencommit branch
+
+[issue]
+  issue.patterns      - Ordered regexes for issues in branch names
+  issue.projects      - Accepted project keys (also matched in lower case)
+  issue.multiple      - Reference every issue found
+  issue.template      - How issue references are added to messages
+
+[tracker]
+  tracker.type        - Issue tracker: github, gitlab, jira or linear
+  tracker.baseurl     - Issue tracker API base URL
+  tracker.token       - Issue tracker API token
+  tracker.project     - GitHub owner/repo or GitLab project path
+  tracker.email       - Jira Cloud account email
+  tracker.max_chars   - Maximum issue text added to the prompt
+  tracker.cache_ttl   - Minutes to reuse fetched issues
 
 Example:
   opencommit config get commit.language
-  opencommit config get api.model`,
+  opencommit config get api.model
+  opencommit config get providers.ollama.model`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
 
 		_, valid := ValidConfigKeys[key]
-		if !valid {
+		providerName, providerField, isProviderKey := parseProviderKey(key)
+		if !valid && !isProviderKey {
 			fmt.Printf("Error: unknown config key '%s'\n", key)
 			fmt.Println("Run 'opencommit config get --help' to see available keys")
 			os.Exit(1)
 		}
 
-		value := viper.Get(key)
+		var value interface{}
+		if isProviderKey {
+			value = getProviderValue(providerName, providerField)
+		} else {
+			value = viper.Get(key)
+		}
 		if value == nil {
 			fmt.Printf("%s = (not set)\n", key)
 		} else {
@@ -8,23 +8,41 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/lorne-luo/open-commit/internal/service"
 )
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all configuration values",
-	Long:  `List all configuration values`,
+	Long: `List all configuration values.
+
+Each value is followed by the layer it comes from: repo (.opencommit.toml at
+the repository root), user (the user config file) or default.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		var user, repo *viper.Viper
 		if cf := viper.ConfigFileUsed(); cf != "" {
-			fmt.Printf("Config file: %s\n\n", cf)
+			fmt.Printf("Config file: %s\n", cf)
+			user, _ = service.ReadConfigFile(cf)
+		}
+		if rc, err := service.RepoConfigPath(); err == nil {
+			if r, err := service.ReadConfigFile(rc); err == nil && len(r.AllKeys()) > 0 {
+				fmt.Printf("Repo config: %s\n", rc)
+				repo = r
+			}
+		}
+		fmt.Println()
+
+		source := func(key string) string {
+			return service.ConfigSource(key, user, repo)
 		}
 		settings := viper.AllSettings()
-		printSettings(settings, "")
+		printSettings(settings, "", source)
 	},
 }
 
-func printSettings(settings map[string]interface{}, prefix string) {
+func printSettings(settings map[string]interface{}, prefix string, source func(string) string) {
 	for key, value := range settings {
 		fullKey := key
 		if prefix != "" {
@@ -32,9 +50,33 @@ func printSettings(settings map[string]interface{}, prefix string) {
 		}
 		switch v := value.(type) {
 		case map[string]interface{}:
-			printSettings(v, fullKey)
+			printSettings(v, fullKey, source)
+		case []interface{}:
+			printTableArray(v, fullKey, source(fullKey))
 		default:
-			fmt.Printf("%s = %v\n", fullKey, v)
+			fmt.Printf("%s = %v  (%s)\n", fullKey, v, source(fullKey))
+		}
+	}
+}
+
+// printTableArray prints a TOML array of tables such as [[providers]],
+// keyed by each entry's name (or index when unnamed).
+func printTableArray(items []interface{}, prefix string, layer string) {
+	for i, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			fmt.Printf("%s[%d] = %v  (%s)\n", prefix, i, item, layer)
+			continue
+		}
+		label := fmt.Sprintf("%s[%d]", prefix, i)
+		if name, ok := entry["name"].(string); ok && name != "" {
+			label = prefix + "." + name
+		}
+		for key, value := range entry {
+			if key == "name" {
+				continue
+			}
+			fmt.Printf("%s.%s = %v  (%s)\n", label, key, value, layer)
 		}
 	}
 }
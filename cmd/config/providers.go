@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ValidProviderFields are the settable fields of a [[providers]] entry,
+// addressed as providers.<name>.<field>.
+var ValidProviderFields = map[string]string{
+	"type":    "string",
+	"key":     "string",
+	"baseurl": "string",
+	"model":   "string",
+}
+
+// parseProviderKey splits "providers.<name>.<field>". Names may not contain dots.
+func parseProviderKey(key string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(key, "providers.")
+	if !ok {
+		return "", "", false
+	}
+	name, field, ok := strings.Cut(rest, ".")
+	if !ok || name == "" || strings.Contains(field, ".") {
+		return "", "", false
+	}
+	if _, valid := ValidProviderFields[field]; !valid {
+		return "", "", false
+	}
+	return name, field, true
+}
+
+// providerEntries returns the raw [[providers]] array as a list of tables.
+func providerEntries() []map[string]interface{} {
+	raw, _ := viper.Get("providers").([]interface{})
+	entries := make([]map[string]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, m)
+		}
+	}
+	if typed, ok := viper.Get("providers").([]map[string]interface{}); ok {
+		entries = append(entries, typed...)
+	}
+	return entries
+}
+
+// getProviderValue returns providers.<name>.<field>, or nil when unset.
+func getProviderValue(name, field string) interface{} {
+	for _, e := range providerEntries() {
+		if e["name"] == name {
+			return e[field]
+		}
+	}
+	return nil
+}
+
+// setProviderValue updates providers.<name>.<field>, appending a new entry
+// to the end of the chain when no provider has that name yet. It returns the
+// updated chain.
+func setProviderValue(name, field string, value interface{}) []map[string]interface{} {
+	entries := providerEntries()
+	found := false
+	for _, e := range entries {
+		if e["name"] == name {
+			e[field] = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		entries = append(entries, map[string]interface{}{"name": name, field: value})
+	}
+	viper.Set("providers", entries)
+	return entries
+}
+
+// validateEnumValue checks keys that only accept a fixed set of values.
+func validateEnumValue(key, value string) error {
+	allowed, ok := EnumConfigValues[key]
+	if !ok {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("value '%s' is not valid for %s (use %s)", value, key, strings.Join(allowed, ", "))
+}
@@ -7,26 +7,35 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/lorne-luo/open-commit/internal/service"
 )
 
 // ValidConfigKeys defines all valid configuration keys and their types
 var ValidConfigKeys = map[string]string{
 	// [api]
-	"api.key":          "string",
-	"api.model":        "string",
-	"api.baseurl":      "string",
-	"api.last_success": "int",
+	"api.key":           "string",
+	"api.model":         "string",
+	"api.baseurl":       "string",
+	"api.type":          "string",
+	"api.order":         "string",
+	"api.last_provider": "string",
 	// [api2] — secondary provider for fallback
 	"api2.key":     "string",
 	"api2.model":   "string",
 	"api2.baseurl": "string",
+	"api2.type":    "string",
 	// [commit]
-	"commit.language":   "string",
-	"commit.max_length":     "int",
-	"commit.max_diff_lines": "int",
+	"commit.language":        "string",
+	"commit.max_length":      "int",
+	"commit.max_diff_lines":  "int",
+	"commit.summarize":       "bool",
+	"commit.token_budget":    "int",
+	"commit.summary_workers": "int",
 	// [behavior]
 	"behavior.stage_all":   "bool",
 	"behavior.auto_select": "bool",
@@ -36,8 +45,61 @@ var ValidConfigKeys = map[string]string{
 	"behavior.dry_run":     "bool",
 	"behavior.show_diff":   "bool",
 	"behavior.no_verify":   "bool",
+	"behavior.no_stream":   "bool",
+	"behavior.output":      "string",
+	// [lint]
+	"lint.enabled":              "bool",
+	"lint.types":                "list",
+	"lint.scopes":               "list",
+	"lint.subject_case":         "string",
+	"lint.subject_full_stop":    "bool",
+	"lint.header_max_length":    "int",
+	"lint.body_leading_blank":   "bool",
+	"lint.body_max_line_length": "int",
+	"lint.max_retries":          "int",
+	// [style]
+	"style.enabled":     "bool",
+	"style.sample_size": "int",
+	"style.branch":      "string",
+	// [secret]
+	"secret.policy":    "string",
+	"secret.rules":     "list",
+	"secret.allowlist": "list",
+	// [ignore]
+	"ignore.patterns": "list",
+	// [release]
+	"release.tag_prefix": "string",
+	// [branch]
+	"branch.pattern": "string",
+	// [issue]
+	"issue.patterns": "list",
+	"issue.projects": "list",
+	"issue.multiple": "bool",
+	"issue.template": "string",
+	// [tracker]
+	"tracker.type":      "string",
+	"tracker.baseurl":   "string",
+	"tracker.token":     "string",
+	"tracker.project":   "string",
+	"tracker.email":     "string",
+	"tracker.max_chars": "int",
+	"tracker.cache_ttl": "int",
+}
+
+// EnumConfigValues lists the accepted values for keys restricted to a fixed set
+var EnumConfigValues = map[string][]string{
+	"api.type":          {service.ProviderTypeOpenAI, service.ProviderTypeAnthropic},
+	"api2.type":         {service.ProviderTypeOpenAI, service.ProviderTypeAnthropic},
+	"api.order":         {service.ProviderOrderStatic, service.ProviderOrderLastSuccess, service.ProviderOrderRoundRobin},
+	"providers.type":    {service.ProviderTypeOpenAI, service.ProviderTypeAnthropic},
+	"lint.subject_case": {service.SubjectCaseLower, service.SubjectCaseAny},
+	"behavior.output":   {service.OutputText, service.OutputJSON},
+	"tracker.type":      {service.TrackerGitHub, service.TrackerGitLab, service.TrackerJira, service.TrackerLinear},
+	"secret.policy":     {service.SecretPolicyRedact, service.SecretPolicyWarn, service.SecretPolicyAbort, service.SecretPolicyOff},
 }
 
+var setLocal bool
+
 // setCmd represents the set command
 var setCmd = &cobra.Command{
 	Use:   "set <key> <value>",
@@ -48,17 +110,30 @@ var setCmd = &cobra.Command{
   api.key             - AI provider API key
   api.model           - AI provider model name (default: gpt-3.5-turbo)
   api.baseurl         - Custom base URL for AI provider API
-  api.last_success    - Provider used last successfully: 1 or 2 (managed automatically)
+  api.type            - Provider protocol: openai or anthropic (default: openai)
+  api.order           - Provider order: static, last_success or round_robin (default: last_success)
+  api.last_provider   - Name of the provider used last successfully (managed automatically)
 
 [api2] (optional secondary provider — used when api1 fails)
   api2.key            - Secondary AI provider API key
   api2.model          - Secondary AI provider model name
   api2.baseurl        - Secondary custom base URL for AI provider API
+  api2.type           - Secondary provider protocol: openai or anthropic
+
+[[providers]] (optional provider chain — replaces [api]/[api2] when present)
+  providers.<name>.type    - Provider protocol: openai or anthropic
+  providers.<name>.key     - API key (may be empty for local servers)
+  providers.<name>.baseurl - Base URL for the provider API
+  providers.<name>.model   - Model name
+  Setting a field of an unknown <name> appends a new provider to the chain.
 
 [commit]
   commit.language     - Language for commit messages (default: english)
   commit.max_length     - Maximum length of commit message (default: 72)
   commit.max_diff_lines - Truncate per-file diff to N lines to save tokens (default: 500, 0 disables)
+  commit.summarize      - Summarize each file with AI when the diff exceeds the token budget (default: false)
+  commit.token_budget   - Approximate token budget for the diff sent to the model (default: 12000)
+  commit.summary_workers - Concurrent per-file summary requests (default: 4)
 
 [behavior]
   behavior.stage_all   - Stage all changes in tracked files (default: false)
@@ -69,17 +144,83 @@ var setCmd = &cobra.Command{
   behavior.dry_run     - Run without making changes (default: false)
   behavior.show_diff   - Show diff before committing (default: false)
   behavior.no_verify   - Skip git commit-msg hook verification (default: false)
+  behavior.no_stream   - Show a spinner instead of streaming output (default: false)
+  behavior.output      - Output format: text or json (default: text)
+
+[lint] (Conventional Commits checks applied to generated messages)
+  lint.enabled              - Validate and repair generated messages (default: true)
+  lint.types                - Comma-separated allowed types (default: feat,fix,docs,style,refactor,perf,test,chore,ci,build,revert)
+  lint.scopes               - Comma-separated allowed scopes (default: any)
+  lint.subject_case         - Description case: lower or any (default: lower)
+  lint.subject_full_stop    - Forbid a trailing period in the description (default: true)
+  lint.header_max_length    - Maximum header length (default: commit.max_length)
+  lint.body_leading_blank   - Require a blank line before the body (default: true)
+  lint.body_max_line_length - Maximum body line length, 0 disables (default: 0)
+  lint.max_retries          - Re-prompts when violations remain after repair (default: 1)
+
+[style] (conventions learned from the repository's history)
+  style.enabled       - Add style guidance and example commits to the prompt (default: true)
+  style.sample_size   - Number of recent commits to learn from (default: 50)
+  style.branch        - Branch or revision to sample (default: HEAD)
+
+[secret] (scan added lines for credentials before the diff is sent)
+  secret.policy       - redact, warn, abort or off (default: redact)
+  secret.rules        - Comma-separated extra rules, as name=regex or regex
+  secret.allowlist    - Comma-separated regexes for values or paths to ignore
+
+[ignore] (files committed as usual but left out of the prompt)
+  ignore.patterns     - Comma-separated gitignore-style patterns, added to the defaults
+                        and .opencommitignore; prefix with ! to re-include a default
+
+[release]
+  release.tag_prefix  - Prefix for release tags (default: as the last release tag, else v)
+
+[branch]
+  branch.pattern      - Branch name pattern with {type}, {issue} and {slug} (default: {type}/{issue}-{slug})
+
+[issue] (issues detected from the branch name or given with --issue)
+  issue.patterns      - Comma-separated regexes tried in order; the "key" group is the issue
+  issue.projects      - Comma-separated project keys to accept, e.g. ABC,OPS (default: any
+                        upper-case key); listed keys also match in lower case, e.g. abc-12
+  issue.multiple      - Reference every issue found instead of the first (default: false)
+  issue.template      - How references are added: a footer such as "Closes {issue}", or
+                        a subject such as "[{issues}] {subject}" (default: Refs: {issues})
+
+[tracker] (issue title and description added to the prompt)
+  tracker.type        - github, gitlab, jira or linear (default: not set, nothing is fetched)
+  tracker.baseurl     - API base URL (default: the public service; required for jira)
+  tracker.token       - API token (default: GITHUB_TOKEN, GITLAB_TOKEN, JIRA_API_TOKEN or LINEAR_API_KEY)
+  tracker.project     - GitHub owner/repo or GitLab project path (default: from the remote URL)
+  tracker.email       - Jira Cloud account email, to use the token with basic auth
+  tracker.max_chars   - Maximum issue text added to the prompt (default: 2000)
+  tracker.cache_ttl   - Minutes to reuse fetched issues, 0 disables the cache (default: 60)
+
+Values set with --local are written to .opencommit.toml at the repository
+root and override the user config for that repository. API keys, tokens, base
+URLs, api.last_provider, [[providers]], [[forges]], secret.*, behavior.push,
+behavior.no_verify, behavior.no_confirm and ! patterns in ignore.patterns can
+only be set in the user config; ignore.patterns set here add to the user's.
 
 Example:
   opencommit config set commit.language korean
+  opencommit config set --local commit.max_length 50
   opencommit config set commit.max_length 100
-  opencommit config set behavior.push true`,
+  opencommit config set behavior.push true
+  opencommit config set providers.ollama.baseurl http://localhost:11434/v1
+  opencommit config set api.order round_robin
+  opencommit config set lint.scopes api,cli,docs`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
 		value := args[1]
 
 		keyType, valid := ValidConfigKeys[key]
+		enumKey := key
+		providerName, providerField, isProviderKey := parseProviderKey(key)
+		if isProviderKey {
+			keyType, valid = ValidProviderFields[providerField], true
+			enumKey = "providers." + providerField
+		}
 		if !valid {
 			fmt.Printf("Error: unknown config key '%s'\n", key)
 			fmt.Println("Run 'opencommit config set --help' to see available keys")
@@ -102,19 +243,61 @@ Example:
 				os.Exit(1)
 			}
 			finalValue = boolVal
+		case "list":
+			items := []string{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			finalValue = items
 		default:
+			if err := validateEnumValue(enumKey, value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
 			finalValue = value
 		}
 
-		viper.Set(key, finalValue)
-		if err := viper.WriteConfig(); err != nil {
+		configFile := viper.ConfigFileUsed()
+		if setLocal {
+			if !service.IsRepoConfigKeyAllowed(key) {
+				fmt.Printf("Error: %s can only be set in the user config\n", key)
+				os.Exit(1)
+			}
+			if items, ok := finalValue.([]string); ok && key == "ignore.patterns" {
+				if _, dropped := service.RepoIgnorePatterns(items); dropped {
+					fmt.Println("Error: ! patterns in ignore.patterns can only be set in the user config")
+					os.Exit(1)
+				}
+			}
+			repoConfig, err := service.RepoConfigPath()
+			if err != nil {
+				fmt.Printf("Error: --local must be used inside a git repository: %v\n", err)
+				os.Exit(1)
+			}
+			configFile = repoConfig
+		}
+
+		writeKey, writeValue := key, finalValue
+		if isProviderKey {
+			writeKey, writeValue = "providers", setProviderValue(providerName, providerField, finalValue)
+		} else {
+			viper.Set(key, finalValue)
+		}
+		if err := service.UpdateConfigFile(configFile, writeKey, writeValue); err != nil {
 			fmt.Printf("Error: failed to write config: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Set %s = %v\n", key, finalValue)
+		if setLocal {
+			fmt.Printf("Set %s = %v in %s\n", key, finalValue, configFile)
+		} else {
+			fmt.Printf("Set %s = %v\n", key, finalValue)
+		}
 	},
 }
 
 func init() {
 	ConfigCmd.AddCommand(setCmd)
+	setCmd.Flags().BoolVar(&setLocal, "local", false, "write to the repository's "+service.RepoConfigFileName+" instead of the user config")
 }
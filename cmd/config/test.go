@@ -7,64 +7,46 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/sashabaranov/go-openai"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/lorne-luo/open-commit/internal/service"
 )
 
-var testOnlyProvider int
+var testOnlyProvider string
 
 var testCmd = &cobra.Command{
 	Use:   "test",
 	Short: "Test each configured AI provider",
 	Long: `Send a minimal request to each configured AI provider and report
-whether it responded successfully. Does not modify api.last_success.
+whether it responded successfully. Does not modify api.last_provider.
+
+Providers are named api1/api2 for the legacy [api]/[api2] sections, or by
+their name in the [[providers]] array.
 
 Examples:
   opencommit config test
-  opencommit config test --provider 2`,
+  opencommit config test --provider api2
+  opencommit config test --provider ollama`,
 	Run: func(cmd *cobra.Command, args []string) {
-		primary := service.ProviderConfig{
-			ID:      1,
-			Key:     viper.GetString("api.key"),
-			BaseURL: viper.GetString("api.baseurl"),
-			Model:   viper.GetString("api.model"),
-		}
-		if primary.Model == "" {
-			primary.Model = service.DefaultModel
-		}
-		secondary := service.ProviderConfig{
-			ID:      2,
-			Key:     viper.GetString("api2.key"),
-			BaseURL: viper.GetString("api2.baseurl"),
-			Model:   viper.GetString("api2.model"),
-		}
-		if secondary.Model == "" {
-			secondary.Model = service.DefaultModel
-		}
-
 		var candidates []service.ProviderConfig
-		if testOnlyProvider == 0 || testOnlyProvider == 1 {
-			candidates = append(candidates, primary)
-		}
-		if testOnlyProvider == 0 || testOnlyProvider == 2 {
-			candidates = append(candidates, secondary)
+		for _, p := range service.ConfiguredProviders(service.ProviderOverride{}) {
+			if testOnlyProvider == "" || testOnlyProvider == p.Name {
+				candidates = append(candidates, p)
+			}
 		}
-		if testOnlyProvider != 0 && testOnlyProvider != 1 && testOnlyProvider != 2 {
-			fmt.Fprintln(os.Stderr, "Error: --provider must be 1 or 2")
+		if len(candidates) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no provider named '%s'\n", testOnlyProvider)
 			os.Exit(1)
 		}
 
 		anyFailed := false
 		for _, p := range candidates {
-			label := fmt.Sprintf("api%d", p.ID)
-			if p.Key == "" {
-				color.New(color.FgYellow).Printf("%s ⊘ skipped (no key configured)\n", label)
+			if p.Key == "" && p.BaseURL == "" {
+				color.New(color.FgYellow).Printf("%s ⊘ skipped (no key configured)\n", p.Name)
 				continue
 			}
-			fmt.Printf("%s → model=%s baseurl=%s\n", label, p.Model, displayBaseURL(p.BaseURL))
+			providerType, _ := service.ResolveProviderType(p)
+			fmt.Printf("%s → type=%s model=%s baseurl=%s\n", p.Name, providerType, p.Model, displayBaseURL(p.BaseURL))
 			if err := pingProvider(p); err != nil {
 				color.New(color.FgRed).Printf("  ✗ failed: %v\n", err)
 				anyFailed = true
@@ -87,33 +69,13 @@ func displayBaseURL(u string) string {
 }
 
 func pingProvider(p service.ProviderConfig) error {
-	cfg := openai.DefaultConfig(p.Key)
-	if p.BaseURL != "" {
-		cfg.BaseURL = p.BaseURL
-	}
-	client := openai.NewClientWithConfig(cfg)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: p.Model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleUser, Content: "ping"},
-		},
-		MaxTokens:   5,
-		Temperature: 0,
-	})
-	if err != nil {
-		return err
-	}
-	if len(resp.Choices) == 0 {
-		return fmt.Errorf("empty response (no choices)")
-	}
-	return nil
+	return service.PingProvider(ctx, p)
 }
 
 func init() {
-	testCmd.Flags().IntVarP(&testOnlyProvider, "provider", "p", 0, "test only provider 1 or 2 (default: both)")
+	testCmd.Flags().StringVarP(&testOnlyProvider, "provider", "p", "", "test only the named provider (default: all)")
 	ConfigCmd.AddCommand(testCmd)
 }
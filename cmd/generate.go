@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	generateHandler  = handler.NewGenerateHandler()
+	generateDiffFile string
+	generateFiles    []string
+	generateBranch   string
+	generateRepo     = false
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print a commit message for a diff read from stdin or a file",
+	Long: `Print a commit message for a unified diff read from stdin or --diff-file.
+
+The diff goes through the same prompt pipeline as a normal commit (ignore
+patterns, secret scanning, truncation or summarization, linting), but nothing
+is staged or committed and only the message is printed.
+
+The output depends only on the diff, the flags and the user config: the
+repository around the working directory is ignored unless --repo is given,
+which applies its .opencommit.toml, .opencommitignore, .opencommit/prompts
+and learned commit style. --branch names the branch in the prompt.
+
+Examples:
+  git diff --cached | opencommit generate
+  opencommit generate --diff-file change.patch --files src/ --context "hotfix"
+  git diff main... | opencommit generate --repo --branch feat/login
+  git rebase -x 'git show --format= | opencommit generate --repo > /tmp/msg && git commit --amend -F /tmp/msg' main`,
+	Args: cobra.NoArgs,
+	Run: generateHandler.GenerateCommand(
+		context.Background(),
+		&generateDiffFile,
+		&generateFiles,
+		&generateBranch,
+		&generateRepo,
+		&userContext,
+		&model,
+		&maxLength,
+		&language,
+		&issue,
+		&customBaseUrl,
+		&maxDiffLines,
+		&summarize,
+		&tokenBudget,
+		&summaryWorkers,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().
+		StringVarP(&generateDiffFile, "diff-file", "f", "", "read the diff from this file instead of stdin")
+	generateCmd.Flags().
+		StringSliceVar(&generateFiles, "files", nil, "only describe changes to these files or directories")
+	generateCmd.Flags().
+		StringVarP(&generateBranch, "branch", "b", "", "branch name to mention in the prompt")
+	generateCmd.Flags().
+		BoolVarP(&generateRepo, "repo", "", generateRepo, "use the current repository's settings, ignore file, prompts and commit style")
+	generateCmd.Flags().
+		StringVarP(&userContext, "context", "c", "", "additional context to be added to the commit message")
+	generateCmd.Flags().
+		StringVarP(&issue, "issue", "i", "", "issue to reference, e.g. ABC-123 or 42 (comma-separated for several)")
+	generateCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	generateCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	generateCmd.Flags().
+		IntVarP(&maxLength, "max-length", "l", maxLength, "maximum length of the commit message")
+	generateCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the commit message")
+	generateCmd.Flags().
+		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+	generateCmd.Flags().
+		BoolVarP(&summarize, "summarize", "", summarize, "summarize each file with AI when the diff exceeds the token budget")
+	generateCmd.Flags().
+		IntVarP(&tokenBudget, "token-budget", "", tokenBudget, "approximate token budget for the diff sent to the model")
+	generateCmd.Flags().
+		IntVarP(&summaryWorkers, "summary-workers", "", summaryWorkers, "number of concurrent per-file summary requests")
+}
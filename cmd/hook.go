@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+)
+
+var hookHandler = handler.NewHookHandler()
+
+// hookCmd represents the hook command
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the prepare-commit-msg hook so plain `git commit` gets AI messages",
+	Long: `Manage the prepare-commit-msg hook so plain "git commit" (and IDEs that
+call it) get an AI-generated message pre-filled in the editor.
+
+The hook is written to the repository's hooks directory, honouring
+core.hooksPath. An existing prepare-commit-msg hook is kept as
+prepare-commit-msg.opencommit-backup and runs first.
+
+Examples:
+  opencommit hook install
+  opencommit hook status
+  opencommit hook uninstall`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg hook in the current repository",
+	Args:  cobra.NoArgs,
+	Run:   hookHandler.InstallCommand(),
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the hook and restore any previous prepare-commit-msg hook",
+	Args:  cobra.NoArgs,
+	Run:   hookHandler.UninstallCommand(),
+}
+
+var hookStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the hook is installed",
+	Args:  cobra.NoArgs,
+	Run:   hookHandler.StatusCommand(),
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:   "run <msg-file> [<source> [<sha>]]",
+	Short: "Hook entrypoint: write an AI message for the staged changes into <msg-file>",
+	Long: `Hook entrypoint called by the installed prepare-commit-msg hook with git's
+arguments. It runs non-interactively using the config file settings and
+skips merges, squashes, amends and messages given with -m/-F.`,
+	Args: cobra.RangeArgs(1, 3),
+	Run: hookHandler.RunCommand(
+		context.Background(),
+		&model,
+		&maxLength,
+		&language,
+		&issue,
+		&userContext,
+		&maxDiffLines,
+		&summarize,
+		&tokenBudget,
+		&summaryWorkers,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookStatusCmd)
+	hookCmd.AddCommand(hookRunCmd)
+}
@@ -13,15 +13,35 @@ import (
 )
 
 var (
-	prHandler = handler.NewPRHandler()
-	draft     = false
+	prHandler   = handler.NewPRHandler()
+	draft       = false
+	prBase      string
+	prLabels    []string
+	prAssignees []string
 )
 
 // prCmd represents the pr command
 var prCmd = &cobra.Command{
 	Use:   "pr",
 	Short: "Create a pull request with a conventional commit title",
-	Long:  `Create a pull request with a conventional commit title`,
+	Long: `Create a pull request with a conventional commit title.
+
+The forge is detected from the remote URL: github.com uses gh, and hosts with
+"gitlab" in their name open merge requests through the GitLab API (with a
+token from [[forges]], or GITLAB_TOKEN for gitlab.com and hosts declared in
+[[forges]]) or glab. Gitea/Forgejo (codeberg.org,
+GITEA_TOKEN) and Bitbucket Cloud (bitbucket.org, BITBUCKET_TOKEN) use their
+REST APIs; environment tokens only go to those hosts and hosts declared in
+[[forges]]. Other hosts, such as self-hosted instances, are declared in
+[[forges]].
+
+If the branch already has an open pull request, it is regenerated and
+updated instead. Only the description between the <!-- opencommit:start -->
+and <!-- opencommit:end --> markers is replaced.
+
+Examples:
+  opencommit pr --draft
+  opencommit pr --base develop --label bug --label backend --assignee alice`,
 	Run: prHandler.PRCommand(
 		context.Background(),
 		&model,
@@ -33,8 +53,16 @@ var prCmd = &cobra.Command{
 		&language,
 		&userContext,
 		&draft,
+		&prBase,
+		&prLabels,
+		&prAssignees,
 		&customBaseUrl,
 		&maxDiffLines,
+		&noStream,
+		&summarize,
+		&tokenBudget,
+		&summaryWorkers,
+		&output,
 	),
 }
 
@@ -67,8 +95,24 @@ func init() {
 		StringVarP(&userContext, "context", "c", "", "additional context to be added to the pull request title")
 	prCmd.Flags().
 		BoolVar(&draft, "draft", draft, "create a draft pull request")
+	prCmd.Flags().
+		StringVarP(&prBase, "base", "b", "", "target branch (default: the remote's default branch)")
+	prCmd.Flags().
+		StringSliceVar(&prLabels, "label", nil, "add a label (repeatable or comma-separated)")
+	prCmd.Flags().
+		StringSliceVar(&prAssignees, "assignee", nil, "assign a user by username (repeatable or comma-separated)")
 	prCmd.Flags().
 		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
 	prCmd.Flags().
 		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+	prCmd.Flags().
+		BoolVarP(&summarize, "summarize", "", summarize, "summarize each file with AI when the diff exceeds the token budget")
+	prCmd.Flags().
+		IntVarP(&tokenBudget, "token-budget", "", tokenBudget, "approximate token budget for the diff sent to the model")
+	prCmd.Flags().
+		IntVarP(&summaryWorkers, "summary-workers", "", summaryWorkers, "number of concurrent per-file summary requests")
+	prCmd.Flags().
+		BoolVarP(&noStream, "no-stream", "", noStream, "show a spinner instead of streaming the message as it is generated")
+	prCmd.Flags().
+		StringVarP(&output, "output", "o", output, "output format: text or json (json implies --yes and --quiet)")
 }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	promptHandler = handler.NewPromptHandler()
+	promptRaw     = false
+)
+
+// promptCmd represents the prompt command
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Inspect the prompt templates sent to the AI provider",
+	Long: `Inspect the prompt templates sent to the AI provider.
+
+Each embedded prompt can be overridden by a text/template file named
+<name>.md, looked up first in .opencommit/prompts/ at the repository root and
+then in prompts/ next to the config file in use. Templates can use:
+
+  {{.Diff}}          the (possibly truncated) diff
+  {{.Files}}         changed files
+  {{.RelatedFiles}}  neighboring files
+  {{.Context}}       text passed with --context
+  {{.Issue}}         issue reference
+  {{.IssueDetails}}  issue title and description from the tracker
+  {{.Branch}}        current branch
+  {{.Language}}      commit message language
+  {{.MaxLength}}     maximum commit message length
+  {{.Style}}         style guidance learned from history (already appended
+                     to the system prompts)
+
+and the functions join, upper and lower, e.g. {{join .Files ", "}}.
+
+Prompts: ` + strings.Join(service.PromptNames, ", "),
+}
+
+var promptShowCmd = &cobra.Command{
+	Use:   "show [<name>...]",
+	Short: "Print prompts rendered for the staged changes",
+	Long: `Print prompts rendered for the staged changes exactly as they would be sent,
+with the source of each template. Defaults to the system and user prompts.
+
+Examples:
+  opencommit prompt show
+  opencommit prompt show combined --issue ABC-12
+  opencommit prompt show --raw system > .opencommit/prompts/system.md`,
+	ValidArgs: service.PromptNames,
+	Args:      cobra.OnlyValidArgs,
+	Run: promptHandler.ShowCommand(
+		&promptRaw,
+		&userContext,
+		&maxLength,
+		&language,
+		&issue,
+		&maxDiffLines,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptShowCmd)
+
+	promptShowCmd.Flags().
+		BoolVarP(&promptRaw, "raw", "", promptRaw, "print the unrendered templates")
+	promptShowCmd.Flags().
+		StringVarP(&userContext, "context", "c", "", "additional context to render into the prompts")
+	promptShowCmd.Flags().
+		StringVarP(&issue, "issue", "i", "", "issue number or title")
+	promptShowCmd.Flags().
+		IntVarP(&maxLength, "max-length", "l", maxLength, "maximum length of the commit message")
+	promptShowCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the commit message")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	releaseHandler   = handler.NewReleaseHandler()
+	releaseBump      string
+	releaseChannel   string
+	releaseTagPrefix string
+	releaseNoAI      = false
+	releasePush      = false
+)
+
+// releaseCmd represents the release command
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Tag the next semantic version from Conventional Commit history",
+	Long: `Compute the next semantic version from the Conventional Commits since the last
+release tag (major for breaking changes, minor for feat, patch for fix, perf
+and revert) and create an annotated tag at HEAD whose message summarizes the
+release.
+
+--pre <channel> makes a pre-release such as v1.3.0-rc.1, numbered after the
+existing pre-releases of that version. The tag prefix follows the last
+release tag, release.tag_prefix or --tag-prefix ("v" for a first release).
+
+Examples:
+  opencommit release --dry-run
+  opencommit release --pre rc
+  opencommit release --bump major --push`,
+	Args: cobra.NoArgs,
+	Run: releaseHandler.ReleaseCommand(
+		context.Background(),
+		&releaseBump,
+		&releaseChannel,
+		&releaseTagPrefix,
+		&releasePush,
+		&releaseNoAI,
+		&model,
+		&language,
+		&customBaseUrl,
+		&noConfirm,
+		&quiet,
+		&dryRun,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(releaseCmd)
+
+	releaseCmd.Flags().
+		StringVarP(&releaseBump, "bump", "", "", "override the computed bump: major, minor or patch")
+	releaseCmd.Flags().
+		StringVarP(&releaseChannel, "pre", "", "", "pre-release channel, e.g. alpha, beta or rc")
+	releaseCmd.Flags().
+		StringVarP(&releaseTagPrefix, "tag-prefix", "", "", "tag prefix, e.g. v (default: as the last release tag)")
+	releaseCmd.Flags().
+		BoolVarP(&releasePush, "push", "p", releasePush, "push the tag to the remote")
+	releaseCmd.Flags().
+		BoolVarP(&releaseNoAI, "no-ai", "", releaseNoAI, "use the changelog entries as the tag message instead of an AI summary")
+	releaseCmd.Flags().
+		BoolVarP(&noConfirm, "yes", "y", noConfirm, "skip confirmation prompt")
+	releaseCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output (only works with --yes)")
+	releaseCmd.Flags().
+		BoolVarP(&dryRun, "dry-run", "", dryRun, "print the plan without creating a tag")
+	releaseCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	releaseCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	releaseCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the tag message")
+}
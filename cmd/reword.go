@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	rewordHandler = handler.NewRewordHandler()
+	rewordForce   = false
+)
+
+// rewordCmd represents the reword command
+var rewordCmd = &cobra.Command{
+	Use:   "reword <range>",
+	Short: "Replace the messages of existing commits with AI-generated ones",
+	Long: `Generate a new message for every commit in <range> from the commit's own
+diff, review them next to the current messages, then rewrite the current
+branch. Trees, authors and author dates are kept; the working tree and index
+are not touched.
+
+<range> must end at HEAD: main..HEAD, main.. or a single base such as HEAD~3.
+Merge commits are refused, and so are commits already on the branch's
+upstream (or on any remote branch when there is no upstream) unless --force
+is given.
+
+Examples:
+  opencommit reword main..
+  opencommit reword HEAD~5 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: rewordHandler.RewordCommand(
+		context.Background(),
+		&userContext,
+		&model,
+		&noConfirm,
+		&quiet,
+		&dryRun,
+		&rewordForce,
+		&maxLength,
+		&language,
+		&customBaseUrl,
+		&maxDiffLines,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(rewordCmd)
+
+	rewordCmd.Flags().
+		BoolVarP(&noConfirm, "yes", "y", noConfirm, "skip the review")
+	rewordCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output (only works with --yes)")
+	rewordCmd.Flags().
+		BoolVarP(&rewordForce, "force", "", rewordForce, "also reword commits already on the upstream or a remote branch")
+	rewordCmd.Flags().
+		StringVarP(&userContext, "context", "c", "", "additional context for every message")
+	rewordCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	rewordCmd.Flags().
+		BoolVarP(&dryRun, "dry-run", "", dryRun, "show the new messages without rewriting anything")
+	rewordCmd.Flags().
+		IntVarP(&maxLength, "max-length", "l", maxLength, "maximum length of each commit message")
+	rewordCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the commit messages")
+	rewordCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	rewordCmd.Flags().
+		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+}
@@ -18,23 +18,28 @@ import (
 )
 
 var (
-	cfgFile       string
-	stageAll      = false
-	autoSelect    = false
-	userContext   string
-	model         string
-	noConfirm     = false
-	quiet         = false
-	push          = false
-	dryRun        = false
-	showDiff      = false
-	maxLength     = 72
-	language      = "english"
-	issue         string
-	noVerify      = false
-	customBaseUrl string
-	maxDiffLines  = service.DefaultMaxDiffLines
-	rootHandler   = handler.NewRootHandler()
+	cfgFile        string
+	stageAll       = false
+	autoSelect     = false
+	userContext    string
+	model          string
+	noConfirm      = false
+	quiet          = false
+	push           = false
+	dryRun         = false
+	showDiff       = false
+	maxLength      = 72
+	language       = "english"
+	issue          string
+	noVerify       = false
+	customBaseUrl  string
+	maxDiffLines   = service.DefaultMaxDiffLines
+	noStream       = false
+	summarize      = false
+	tokenBudget    = service.DefaultTokenBudget
+	summaryWorkers = service.DefaultSummaryWorkers
+	output         = service.OutputText
+	rootHandler    = handler.NewRootHandler()
 )
 
 // RootCmd represents the base command when called without any subcommands
@@ -65,6 +70,11 @@ var RootCmd = &cobra.Command{
 		&noVerify,
 		&customBaseUrl,
 		&maxDiffLines,
+		&noStream,
+		&summarize,
+		&tokenBudget,
+		&summaryWorkers,
+		&output,
 	),
 }
 
@@ -110,13 +120,23 @@ func init() {
 	RootCmd.Flags().
 		StringVarP(&language, "language", "", language, "language of the commit message")
 	RootCmd.Flags().
-		StringVarP(&issue, "issue", "i", "", "issue number or title")
+		StringVarP(&issue, "issue", "i", "", "issue to reference, e.g. ABC-123 or 42 (comma-separated for several)")
 	RootCmd.Flags().
 		BoolVarP(&noVerify, "no-verify", "", noVerify, "skip git commit-msg hook verification")
 	RootCmd.Flags().
 		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
 	RootCmd.Flags().
 		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+	RootCmd.Flags().
+		BoolVarP(&summarize, "summarize", "", summarize, "summarize each file with AI when the diff exceeds the token budget")
+	RootCmd.Flags().
+		IntVarP(&tokenBudget, "token-budget", "", tokenBudget, "approximate token budget for the diff sent to the model")
+	RootCmd.Flags().
+		IntVarP(&summaryWorkers, "summary-workers", "", summaryWorkers, "number of concurrent per-file summary requests")
+	RootCmd.Flags().
+		BoolVarP(&noStream, "no-stream", "", noStream, "show a spinner instead of streaming the message as it is generated")
+	RootCmd.Flags().
+		StringVarP(&output, "output", "o", output, "output format: text or json (json implies --yes and --quiet)")
 
 	// Bind flags to viper config keys
 	// [api]
@@ -126,6 +146,9 @@ func init() {
 	viper.BindPFlag("commit.language", RootCmd.Flags().Lookup("language"))
 	viper.BindPFlag("commit.max_length", RootCmd.Flags().Lookup("max-length"))
 	viper.BindPFlag("commit.max_diff_lines", RootCmd.Flags().Lookup("max-diff-lines"))
+	viper.BindPFlag("commit.summarize", RootCmd.Flags().Lookup("summarize"))
+	viper.BindPFlag("commit.token_budget", RootCmd.Flags().Lookup("token-budget"))
+	viper.BindPFlag("commit.summary_workers", RootCmd.Flags().Lookup("summary-workers"))
 	// [behavior]
 	viper.BindPFlag("behavior.stage_all", RootCmd.Flags().Lookup("all"))
 	viper.BindPFlag("behavior.auto_select", RootCmd.Flags().Lookup("auto"))
@@ -135,6 +158,8 @@ func init() {
 	viper.BindPFlag("behavior.dry_run", RootCmd.Flags().Lookup("dry-run"))
 	viper.BindPFlag("behavior.show_diff", RootCmd.Flags().Lookup("show-diff"))
 	viper.BindPFlag("behavior.no_verify", RootCmd.Flags().Lookup("no-verify"))
+	viper.BindPFlag("behavior.no_stream", RootCmd.Flags().Lookup("no-stream"))
+	viper.BindPFlag("behavior.output", RootCmd.Flags().Lookup("output"))
 }
 
 // applyConfigDefaults applies config values to variables if flags are not explicitly set
@@ -158,6 +183,15 @@ func applyConfigDefaults(cmd *cobra.Command) {
 	if !flags.Changed("max-diff-lines") && viper.IsSet("commit.max_diff_lines") {
 		maxDiffLines = viper.GetInt("commit.max_diff_lines")
 	}
+	if !flags.Changed("summarize") && viper.IsSet("commit.summarize") {
+		summarize = viper.GetBool("commit.summarize")
+	}
+	if !flags.Changed("token-budget") && viper.IsSet("commit.token_budget") {
+		tokenBudget = viper.GetInt("commit.token_budget")
+	}
+	if !flags.Changed("summary-workers") && viper.IsSet("commit.summary_workers") {
+		summaryWorkers = viper.GetInt("commit.summary_workers")
+	}
 	// [behavior]
 	if !flags.Changed("all") && viper.IsSet("behavior.stage_all") {
 		stageAll = viper.GetBool("behavior.stage_all")
@@ -183,6 +217,12 @@ func applyConfigDefaults(cmd *cobra.Command) {
 	if !flags.Changed("no-verify") && viper.IsSet("behavior.no_verify") {
 		noVerify = viper.GetBool("behavior.no_verify")
 	}
+	if !flags.Changed("no-stream") && viper.IsSet("behavior.no_stream") {
+		noStream = viper.GetBool("behavior.no_stream")
+	}
+	if !flags.Changed("output") && viper.IsSet("behavior.output") {
+		output = viper.GetString("behavior.output")
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -208,11 +248,50 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
+	// generate describes a diff that may come from anywhere; the surrounding
+	// repository is only consulted with --repo.
+	if generateCmd.CalledAs() != "" && !generateRepo {
+		service.NewGitService().Detach()
+	}
+
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println("Error: failed to read config")
-		os.Exit(1)
+		exitConfigError(service.Errorf(service.ErrCodeInvalidConfig, "failed to read config: %v", err))
+	}
+
+	// Layer the repository's .opencommit.toml over the user config.
+	repoConfig, ignored, err := service.LoadRepoConfig()
+	if err != nil {
+		exitConfigError(service.Errorf(service.ErrCodeInvalidConfig, "failed to read %s: %v", repoConfig, err))
+	}
+	for _, key := range ignored {
+		warning := fmt.Sprintf("ignoring %s in %s (only allowed in the user config)", key, repoConfig)
+		if jsonOutputRequested() {
+			service.WriteJSONWarning(os.Stderr, service.Errorf(service.ErrCodeInvalidConfig, "%s", warning))
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+}
+
+// jsonOutputRequested reports whether errors found while loading the config
+// should be written as JSON: --output json was given, or behavior.output is
+// json (once a config has been read) and --output was left at its default.
+func jsonOutputRequested() bool {
+	if output != service.OutputText {
+		return output == service.OutputJSON
+	}
+	return viper.GetString("behavior.output") == service.OutputJSON
+}
+
+// exitConfigError reports a config loading failure and exits.
+func exitConfigError(err error) {
+	if jsonOutputRequested() {
+		service.WriteJSONError(os.Stderr, err)
+	} else {
+		fmt.Printf("Error: %v\n", err)
 	}
+	os.Exit(1)
 }
 
 func createConfig() {
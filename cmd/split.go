@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var splitHandler = handler.NewSplitHandler()
+
+// splitCmd represents the split command
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split all working tree changes into a sequence of atomic commits",
+	Long: `Ask the AI to partition every change in the working tree (tracked and
+untracked) into an ordered plan of atomic commits. Review or edit the plan,
+then each commit is staged and created in order.
+
+If a commit fails part-way, the index is reset so the remaining changes stay
+unstaged, and the command prints how to undo the commits already created.
+
+Changes that are already staged would be unstaged, so split asks first, and
+refuses with --yes; stash or commit them to keep them.`,
+	Run: splitHandler.SplitCommand(
+		context.Background(),
+		&userContext,
+		&model,
+		&noConfirm,
+		&quiet,
+		&dryRun,
+		&maxLength,
+		&language,
+		&issue,
+		&noVerify,
+		&customBaseUrl,
+		&maxDiffLines,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().
+		BoolVarP(&noConfirm, "yes", "y", noConfirm, "skip plan review")
+	splitCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output (only works with --yes)")
+	splitCmd.Flags().
+		StringVarP(&userContext, "context", "c", "", "additional context to guide the split")
+	splitCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	splitCmd.Flags().
+		BoolVarP(&dryRun, "dry-run", "", dryRun, "show the plan without making any changes")
+	splitCmd.Flags().
+		IntVarP(&maxLength, "max-length", "l", maxLength, "maximum length of each commit message")
+	splitCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the commit messages")
+	splitCmd.Flags().
+		StringVarP(&issue, "issue", "i", "", "issue to reference, e.g. ABC-123 or 42 (comma-separated for several)")
+	splitCmd.Flags().
+		BoolVarP(&noVerify, "no-verify", "", noVerify, "skip git commit-msg hook verification")
+	splitCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	splitCmd.Flags().
+		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/delivery/cli/handler"
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+var (
+	squashHandler = handler.NewSquashHandler()
+	squashBase    string
+	squashCommit  = false
+)
+
+// squashCmd represents the squash-message command
+var squashCmd = &cobra.Command{
+	Use:   "squash-message",
+	Short: "Write one commit message for all commits on the branch",
+	Long: `Write a single Conventional Commit message for a squash merge of the current
+branch, from the subjects and bodies of every commit since --base and the net
+diff. Issue references and BREAKING CHANGE footers from those commits are kept.
+
+By default only the message is printed. With --commit the branch is
+soft-reset to the merge base and the squashed commit is created after review.
+
+Examples:
+  opencommit squash-message
+  opencommit squash-message --base develop > squash-msg.txt
+  opencommit squash-message --commit`,
+	Args: cobra.NoArgs,
+	Run: squashHandler.SquashMessageCommand(
+		context.Background(),
+		&squashBase,
+		&squashCommit,
+		&userContext,
+		&model,
+		&noConfirm,
+		&quiet,
+		&dryRun,
+		&noVerify,
+		&maxLength,
+		&language,
+		&customBaseUrl,
+		&maxDiffLines,
+	),
+}
+
+func init() {
+	RootCmd.AddCommand(squashCmd)
+
+	squashCmd.Flags().
+		StringVarP(&squashBase, "base", "b", "", "branch the work will be merged into (default: the remote's HEAD branch, main or master)")
+	squashCmd.Flags().
+		BoolVarP(&squashCommit, "commit", "", squashCommit, "replace the branch's commits with the squashed commit")
+	squashCmd.Flags().
+		BoolVarP(&noConfirm, "yes", "y", noConfirm, "skip confirmation prompt (with --commit)")
+	squashCmd.Flags().
+		BoolVarP(&quiet, "quiet", "q", quiet, "suppress output (only works with --yes)")
+	squashCmd.Flags().
+		StringVarP(&userContext, "context", "c", "", "additional context to be added to the commit message")
+	squashCmd.Flags().
+		StringVarP(&model, "model", "m", service.DefaultModel, "AI model to use")
+	squashCmd.Flags().
+		BoolVarP(&dryRun, "dry-run", "", dryRun, "show the message without squashing (with --commit)")
+	squashCmd.Flags().
+		BoolVarP(&noVerify, "no-verify", "", noVerify, "skip git commit-msg hook verification")
+	squashCmd.Flags().
+		IntVarP(&maxLength, "max-length", "l", maxLength, "maximum length of the commit message")
+	squashCmd.Flags().
+		StringVarP(&language, "language", "", language, "language of the commit message")
+	squashCmd.Flags().
+		StringVarP(&customBaseUrl, "baseurl", "", service.DefaultBaseUrl, "specify custom url for AI API")
+	squashCmd.Flags().
+		IntVarP(&maxDiffLines, "max-diff-lines", "", maxDiffLines, "truncate per-file diff to N lines to save tokens (0 disables)")
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type BranchHandler struct {
+	useCase *usecase.BranchUsecase
+}
+
+var (
+	branchHandlerInstance *BranchHandler
+	branchHandlerOnce     sync.Once
+)
+
+func NewBranchHandler() *BranchHandler {
+	branchHandlerOnce.Do(func() {
+		useCase := usecase.NewBranchUsecase()
+
+		branchHandlerInstance = &BranchHandler{useCase}
+	})
+
+	return branchHandlerInstance
+}
+
+func (b *BranchHandler) BranchCommand(
+	ctx context.Context,
+	issue *string,
+	pattern *string,
+	model *string,
+	customBaseUrl *string,
+	maxDiffLines *int,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if *quiet && !*noConfirm {
+			*quiet = false
+		}
+
+		providers := resolveProviders(cmd, model, customBaseUrl, nil)
+
+		err := b.useCase.BranchCommand(
+			ctx,
+			providers,
+			strings.TrimSpace(strings.Join(args, " ")),
+			issue,
+			pattern,
+			model,
+			maxDiffLines,
+			noConfirm,
+			quiet,
+			dryRun,
+		)
+		cobra.CheckErr(err)
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type ChangelogHandler struct {
+	useCase *usecase.ChangelogUsecase
+}
+
+var (
+	changelogHandlerInstance *ChangelogHandler
+	changelogHandlerOnce     sync.Once
+)
+
+func NewChangelogHandler() *ChangelogHandler {
+	changelogHandlerOnce.Do(func() {
+		useCase := usecase.NewChangelogUsecase()
+
+		changelogHandlerInstance = &ChangelogHandler{useCase}
+	})
+
+	return changelogHandlerInstance
+}
+
+func (c *ChangelogHandler) ChangelogCommand(
+	ctx context.Context,
+	from *string,
+	to *string,
+	version *string,
+	format *string,
+	file *string,
+	includeAll *bool,
+	polish *bool,
+	model *string,
+	language *string,
+	customBaseUrl *string,
+	quiet *bool,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		// The AI is only needed to polish the notes.
+		var providers []service.ProviderConfig
+		if *polish {
+			providers = resolveProviders(cmd, model, customBaseUrl, nil)
+		}
+
+		err := c.useCase.ChangelogCommand(
+			ctx,
+			providers,
+			from,
+			to,
+			version,
+			format,
+			file,
+			includeAll,
+			polish,
+			model,
+			language,
+			quiet,
+		)
+		cobra.CheckErr(err)
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type GenerateHandler struct {
+	useCase *usecase.GenerateUsecase
+}
+
+var (
+	generateHandlerInstance *GenerateHandler
+	generateHandlerOnce     sync.Once
+)
+
+func NewGenerateHandler() *GenerateHandler {
+	generateHandlerOnce.Do(func() {
+		useCase := usecase.NewGenerateUsecase()
+
+		generateHandlerInstance = &GenerateHandler{useCase}
+	})
+
+	return generateHandlerInstance
+}
+
+func (g *GenerateHandler) GenerateCommand(
+	ctx context.Context,
+	diffFile *string,
+	files *[]string,
+	branch *string,
+	repo *bool,
+	userContext *string,
+	model *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	customBaseUrl *string,
+	maxDiffLines *int,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		providers := resolveProviders(cmd, model, customBaseUrl, nil)
+
+		err := g.useCase.GenerateCommand(
+			ctx,
+			providers,
+			diffFile,
+			files,
+			branch,
+			repo,
+			userContext,
+			model,
+			maxLength,
+			language,
+			issue,
+			maxDiffLines,
+			summarize,
+			tokenBudget,
+			summaryWorkers,
+		)
+		cobra.CheckErr(err)
+	}
+}
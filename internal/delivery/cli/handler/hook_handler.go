@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type HookHandler struct {
+	useCase *usecase.HookUsecase
+}
+
+var (
+	hookHandlerInstance *HookHandler
+	hookHandlerOnce     sync.Once
+)
+
+func NewHookHandler() *HookHandler {
+	hookHandlerOnce.Do(func() {
+		useCase := usecase.NewHookUsecase()
+
+		hookHandlerInstance = &HookHandler{useCase}
+	})
+
+	return hookHandlerInstance
+}
+
+func (h *HookHandler) InstallCommand() func(*cobra.Command, []string) {
+	return func(_ *cobra.Command, _ []string) {
+		cobra.CheckErr(h.useCase.Install())
+	}
+}
+
+func (h *HookHandler) UninstallCommand() func(*cobra.Command, []string) {
+	return func(_ *cobra.Command, _ []string) {
+		cobra.CheckErr(h.useCase.Uninstall())
+	}
+}
+
+func (h *HookHandler) StatusCommand() func(*cobra.Command, []string) {
+	return func(_ *cobra.Command, _ []string) {
+		cobra.CheckErr(h.useCase.Status())
+	}
+}
+
+// RunCommand is invoked by the installed hook as
+// `opencommit hook run <msg-file> [<source> [<sha>]]`. It never fails the
+// commit: problems are reported on stderr and the message is left as is.
+func (h *HookHandler) RunCommand(
+	ctx context.Context,
+	model *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	userContext *string,
+	maxDiffLines *int,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+) func(*cobra.Command, []string) {
+	return func(_ *cobra.Command, args []string) {
+		warn := color.New(color.FgYellow)
+
+		providers := service.BuildProviders(service.ProviderOverride{})
+		if len(providers) == 0 {
+			warn.Fprintln(os.Stderr, "opencommit: no AI provider configured, skipping message generation")
+			return
+		}
+		*model = providers[0].Model
+
+		source := ""
+		if len(args) > 1 {
+			source = args[1]
+		}
+
+		quiet := true
+		noConfirm := true
+		opts := &service.CommitOptions{
+			UserContext:    userContext,
+			Model:          model,
+			NoConfirm:      &noConfirm,
+			Quiet:          &quiet,
+			MaxLength:      maxLength,
+			Language:       language,
+			Issue:          issue,
+			MaxDiffLines:   maxDiffLines,
+			Summarize:      summarize,
+			TokenBudget:    tokenBudget,
+			SummaryWorkers: summaryWorkers,
+		}
+
+		if err := h.useCase.Run(ctx, providers, args[0], source, opts); err != nil {
+			warn.Fprintf(os.Stderr, "opencommit: failed to generate commit message: %v\n", err)
+		}
+	}
+}
@@ -5,15 +5,10 @@ package handler
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"sync"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
-	"github.com/lorne-luo/open-commit/internal/service"
 	"github.com/lorne-luo/open-commit/internal/usecase"
 )
 
@@ -47,32 +42,24 @@ func (p *PRHandler) PRCommand(
 	language *string,
 	userContext *string,
 	draft *bool,
+	base *string,
+	labels *[]string,
+	assignees *[]string,
 	customBaseUrl *string,
 	maxDiffLines *int,
+	noStream *bool,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+	output *string,
 ) func(*cobra.Command, []string) {
-	return func(_ *cobra.Command, _ []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		prepareOutput(output, noConfirm, quiet, noStream)
 		if *quiet && !*noConfirm {
 			*quiet = false
 		}
 
-		primary := service.ProviderConfig{
-			Key:     viper.GetString("api.key"),
-			BaseURL: derefString(customBaseUrl),
-			Model:   derefString(model),
-		}
-		providers := service.BuildProviders(primary)
-
-		if len(providers) == 0 {
-			fmt.Println(
-				"Error: API key is still empty, run this command to set your API key",
-			)
-			fmt.Print("\n")
-			color.New(color.Bold).Print("opencommit config set api.key ")
-			color.New(color.Italic, color.Bold).Print("your_api_key\n\n")
-			os.Exit(1)
-		}
-
-		*model = providers[0].Model
+		providers := resolveProviders(cmd, model, customBaseUrl, output)
 
 		err := p.useCase.PRCommand(
 			ctx,
@@ -86,8 +73,16 @@ func (p *PRHandler) PRCommand(
 			language,
 			userContext,
 			draft,
+			base,
+			labels,
+			assignees,
 			maxDiffLines,
+			noStream,
+			summarize,
+			tokenBudget,
+			summaryWorkers,
+			output,
 		)
-		cobra.CheckErr(err)
+		checkErr(output, err)
 	}
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type PromptHandler struct {
+	useCase *usecase.PromptUsecase
+}
+
+var (
+	promptHandlerInstance *PromptHandler
+	promptHandlerOnce     sync.Once
+)
+
+func NewPromptHandler() *PromptHandler {
+	promptHandlerOnce.Do(func() {
+		useCase := usecase.NewPromptUsecase()
+
+		promptHandlerInstance = &PromptHandler{useCase}
+	})
+
+	return promptHandlerInstance
+}
+
+func (p *PromptHandler) ShowCommand(
+	raw *bool,
+	userContext *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	maxDiffLines *int,
+) func(*cobra.Command, []string) {
+	return func(_ *cobra.Command, args []string) {
+		cobra.CheckErr(p.useCase.Show(args, raw, userContext, maxLength, language, issue, maxDiffLines))
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type ReleaseHandler struct {
+	useCase *usecase.ReleaseUsecase
+}
+
+var (
+	releaseHandlerInstance *ReleaseHandler
+	releaseHandlerOnce     sync.Once
+)
+
+func NewReleaseHandler() *ReleaseHandler {
+	releaseHandlerOnce.Do(func() {
+		useCase := usecase.NewReleaseUsecase()
+
+		releaseHandlerInstance = &ReleaseHandler{useCase}
+	})
+
+	return releaseHandlerInstance
+}
+
+func (r *ReleaseHandler) ReleaseCommand(
+	ctx context.Context,
+	bump *string,
+	channel *string,
+	tagPrefix *string,
+	push *bool,
+	noAI *bool,
+	model *string,
+	language *string,
+	customBaseUrl *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		if *quiet && !*noConfirm {
+			*quiet = false
+		}
+
+		// The AI is only needed to summarize the tag message.
+		var providers []service.ProviderConfig
+		if !*noAI {
+			providers = resolveProviders(cmd, model, customBaseUrl, nil)
+		}
+
+		err := r.useCase.ReleaseCommand(
+			ctx,
+			providers,
+			bump,
+			channel,
+			tagPrefix,
+			push,
+			noAI,
+			model,
+			language,
+			noConfirm,
+			quiet,
+			dryRun,
+		)
+		cobra.CheckErr(err)
+	}
+}
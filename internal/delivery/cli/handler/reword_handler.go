@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type RewordHandler struct {
+	useCase *usecase.RewordUsecase
+}
+
+var (
+	rewordHandlerInstance *RewordHandler
+	rewordHandlerOnce     sync.Once
+)
+
+func NewRewordHandler() *RewordHandler {
+	rewordHandlerOnce.Do(func() {
+		useCase := usecase.NewRewordUsecase()
+
+		rewordHandlerInstance = &RewordHandler{useCase}
+	})
+
+	return rewordHandlerInstance
+}
+
+func (r *RewordHandler) RewordCommand(
+	ctx context.Context,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	force *bool,
+	maxLength *int,
+	language *string,
+	customBaseUrl *string,
+	maxDiffLines *int,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if *quiet && !*noConfirm {
+			*quiet = false
+		}
+
+		providers := resolveProviders(cmd, model, customBaseUrl, nil)
+
+		err := r.useCase.RewordCommand(
+			ctx,
+			providers,
+			args[0],
+			userContext,
+			model,
+			noConfirm,
+			quiet,
+			dryRun,
+			force,
+			maxLength,
+			language,
+			maxDiffLines,
+		)
+		cobra.CheckErr(err)
+	}
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/lorne-luo/open-commit/internal/service"
 	"github.com/lorne-luo/open-commit/internal/usecase"
@@ -21,6 +20,62 @@ func derefString(s *string) string {
 	return *s
 }
 
+// prepareOutput validates --output and, for json, turns off everything that
+// would write to the terminal or wait for input.
+func prepareOutput(output *string, noConfirm *bool, quiet *bool, noStream *bool) {
+	cobra.CheckErr(service.ValidateOutput(*output))
+	if *output == service.OutputJSON {
+		*noConfirm, *quiet, *noStream = true, true, true
+	}
+}
+
+// checkErr exits on err like cobra.CheckErr, writing it as a JSON object on
+// stderr when output is json.
+func checkErr(output *string, err error) {
+	if err != nil && output != nil && *output == service.OutputJSON {
+		service.WriteJSONError(os.Stderr, err)
+		os.Exit(1)
+	}
+	cobra.CheckErr(err)
+}
+
+// resolveProviders builds the provider chain for a command, exiting with the
+// usual hint when none is configured. Only explicitly-set --model/--baseurl
+// flags override the first declared provider; the resolved primary model is
+// reflected back into *model for spinner display.
+func resolveProviders(cmd *cobra.Command, model *string, customBaseUrl *string, output *string) []service.ProviderConfig {
+	override := service.ProviderOverride{}
+	if f := cmd.Flags().Lookup("model"); f != nil && f.Changed {
+		override.Model = derefString(model)
+	}
+	if f := cmd.Flags().Lookup("baseurl"); f != nil && f.Changed {
+		override.BaseURL = derefString(customBaseUrl)
+	}
+	providers := service.BuildProviders(override)
+
+	if len(providers) == 0 {
+		if output != nil && *output == service.OutputJSON {
+			checkErr(output, service.Errorf(
+				service.ErrCodeNoProvider,
+				"API key is empty: set api.key or declare a provider chain with [[providers]]",
+			))
+		}
+		fmt.Println(
+			"Error: API key is still empty, run this command to set your API key",
+		)
+		fmt.Print("\n")
+		color.New(color.Bold).Print("opencommit config set api.key ")
+		color.New(color.Italic, color.Bold).Print("your_api_key\n\n")
+		fmt.Println("or declare a provider chain with [[providers]] in the config file")
+		os.Exit(1)
+	}
+
+	if model != nil {
+		*model = providers[0].Model
+	}
+	return providers
+}
+
 type RootHandler struct {
 	useCase *usecase.RootUsecase
 }
@@ -57,33 +112,19 @@ func (r *RootHandler) RootCommand(
 	noVerify *bool,
 	customBaseUrl *string,
 	maxDiffLines *int,
+	noStream *bool,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+	output *string,
 ) func(*cobra.Command, []string) {
-	return func(_ *cobra.Command, _ []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		prepareOutput(output, noConfirm, quiet, noStream)
 		if *quiet && !*noConfirm {
 			*quiet = false
 		}
 
-		// applyConfigDefaults has already merged config + CLI flags into
-		// `*model` and `*customBaseUrl`, so they are the resolved primary values.
-		primary := service.ProviderConfig{
-			Key:     viper.GetString("api.key"),
-			BaseURL: derefString(customBaseUrl),
-			Model:   derefString(model),
-		}
-		providers := service.BuildProviders(primary)
-
-		if len(providers) == 0 {
-			fmt.Println(
-				"Error: API key is still empty, run this command to set your API key",
-			)
-			fmt.Print("\n")
-			color.New(color.Bold).Print("opencommit config set api.key ")
-			color.New(color.Italic, color.Bold).Print("your_api_key\n\n")
-			os.Exit(1)
-		}
-
-		// Reflect the resolved primary model back to the caller (for spinner display).
-		*model = providers[0].Model
+		providers := resolveProviders(cmd, model, customBaseUrl, output)
 
 		err := r.useCase.RootCommand(
 			ctx,
@@ -102,7 +143,12 @@ func (r *RootHandler) RootCommand(
 			issue,
 			noVerify,
 			maxDiffLines,
+			noStream,
+			summarize,
+			tokenBudget,
+			summaryWorkers,
+			output,
 		)
-		cobra.CheckErr(err)
+		checkErr(output, err)
 	}
 }
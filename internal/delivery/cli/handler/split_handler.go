@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type SplitHandler struct {
+	useCase *usecase.SplitUsecase
+}
+
+var (
+	splitHandlerInstance *SplitHandler
+	splitHandlerOnce     sync.Once
+)
+
+func NewSplitHandler() *SplitHandler {
+	splitHandlerOnce.Do(func() {
+		useCase := usecase.NewSplitUsecase()
+
+		splitHandlerInstance = &SplitHandler{useCase}
+	})
+
+	return splitHandlerInstance
+}
+
+func (s *SplitHandler) SplitCommand(
+	ctx context.Context,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	maxLength *int,
+	language *string,
+	issue *string,
+	noVerify *bool,
+	customBaseUrl *string,
+	maxDiffLines *int,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		if *quiet && !*noConfirm {
+			*quiet = false
+		}
+
+		providers := resolveProviders(cmd, model, customBaseUrl, nil)
+
+		err := s.useCase.SplitCommand(
+			ctx,
+			providers,
+			userContext,
+			model,
+			noConfirm,
+			quiet,
+			dryRun,
+			maxLength,
+			language,
+			issue,
+			noVerify,
+			maxDiffLines,
+		)
+		cobra.CheckErr(err)
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lorne-luo/open-commit/internal/usecase"
+)
+
+type SquashHandler struct {
+	useCase *usecase.SquashUsecase
+}
+
+var (
+	squashHandlerInstance *SquashHandler
+	squashHandlerOnce     sync.Once
+)
+
+func NewSquashHandler() *SquashHandler {
+	squashHandlerOnce.Do(func() {
+		useCase := usecase.NewSquashUsecase()
+
+		squashHandlerInstance = &SquashHandler{useCase}
+	})
+
+	return squashHandlerInstance
+}
+
+func (s *SquashHandler) SquashMessageCommand(
+	ctx context.Context,
+	base *string,
+	commit *bool,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	noVerify *bool,
+	maxLength *int,
+	language *string,
+	customBaseUrl *string,
+	maxDiffLines *int,
+) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		if *quiet && !*noConfirm {
+			*quiet = false
+		}
+
+		providers := resolveProviders(cmd, model, customBaseUrl, nil)
+
+		err := s.useCase.SquashMessageCommand(
+			ctx,
+			providers,
+			base,
+			commit,
+			userContext,
+			model,
+			noConfirm,
+			quiet,
+			dryRun,
+			noVerify,
+			maxLength,
+			language,
+			maxDiffLines,
+		)
+		cobra.CheckErr(err)
+	}
+}
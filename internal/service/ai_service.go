@@ -10,7 +10,6 @@ import (
 
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/fatih/color"
-	"github.com/sashabaranov/go-openai"
 )
 
 //go:embed system_prompt.md
@@ -22,26 +21,38 @@ var fileSelectionPrompt string
 //go:embed combined_prompt.md
 var combinedPrompt string
 
-type AIService struct {
-	systemPrompt string
-}
+type AIService struct{}
 
 // CommitOptions contains options for commit generation
 type CommitOptions struct {
-	StageAll    *bool
-	AutoSelect  *bool
-	UserContext *string
-	Model       *string
-	NoConfirm   *bool
-	Quiet       *bool
-	Push        *bool
-	DryRun      *bool
-	ShowDiff    *bool
-	MaxLength   *int
-	Language    *string
-	Issue       *string
-	NoVerify    *bool
+	StageAll     *bool
+	AutoSelect   *bool
+	UserContext  *string
+	Model        *string
+	NoConfirm    *bool
+	Quiet        *bool
+	Push         *bool
+	DryRun       *bool
+	ShowDiff     *bool
+	MaxLength    *int
+	Language     *string
+	Issue        *string
+	NoVerify     *bool
 	MaxDiffLines *int
+	NoStream     *bool
+	// Summarize replaces per-file truncation with map-reduce summarization
+	// once the diff exceeds TokenBudget.
+	Summarize      *bool
+	TokenBudget    *int
+	SummaryWorkers *int
+	// Output is OutputText or OutputJSON; nil means text.
+	Output *string
+}
+
+// JSONOutput reports whether the command prints a JSON document instead of
+// human-readable output.
+func (o *CommitOptions) JSONOutput() bool {
+	return o.Output != nil && *o.Output == OutputJSON
 }
 
 // PreCommitData contains data about the changes to be committed
@@ -50,6 +61,26 @@ type PreCommitData struct {
 	Diff         string
 	RelatedFiles map[string]string
 	Issue        string
+	Ignored      []string        // files whose changes were omitted from the diff
+	Secrets      []SecretFinding // possible secrets found in the diff
+	Truncated    bool            // per-file diffs were cut to MaxDiffLines
+	Summarized   bool            // the diff was replaced by per-file summaries
+	FullDiff     string          // the diff as captured, before ignore rules, redaction and truncation
+	// Detached marks a diff that does not come from the working tree: prompts
+	// use Branch and Style as given instead of looking them up in the
+	// repository.
+	Detached bool
+	Branch   string
+	Style    *StyleProfile
+}
+
+// HunkDiff returns the diff hunk IDs ("path#N") refer to, or "" when the
+// model was not shown every hunk and so must not pick hunks.
+func (d *PreCommitData) HunkDiff() string {
+	if d.Truncated || d.Summarized {
+		return ""
+	}
+	return d.FullDiff
 }
 
 // SelectFilesAndGenerateCommitOptions contains optional parameters for SelectFilesAndGenerateCommit
@@ -59,6 +90,7 @@ type SelectFilesAndGenerateCommitOptions struct {
 	MaxLength    *int
 	Language     *string
 	Issue        *string
+	HunkDiff     *string // untruncated diff hunk IDs refer to; nil or "" disables hunk selection
 }
 
 var (
@@ -68,9 +100,7 @@ var (
 
 func NewAIService() *AIService {
 	aiOnce.Do(func() {
-		aiService = &AIService{
-			systemPrompt: systemPrompt,
-		}
+		aiService = &AIService{}
 	})
 
 	return aiService
@@ -90,22 +120,33 @@ func (a *AIService) GenerateCommitMessage(
 ) (string, error) {
 	resultChan := make(chan analyzeResult, 1)
 
-	if !*opts.Quiet {
+	if !*opts.Quiet && (opts.NoStream == nil || !*opts.NoStream) {
+		// Stream tokens as a faint live preview; the cleaned-up message is
+		// printed again by the caller once generation has finished.
+		color.New(color.Underline).Printf("AI is writing your commit message. (Model: %s)\n", *opts.Model)
+		preview := color.New(color.Faint)
+		a.analyzeToChannel(providers, ctx, data, opts, resultChan, func(delta string) {
+			preview.Print(delta)
+		})
+		fmt.Println()
+	} else if !*opts.Quiet {
 		if err := spinner.New().
 			Title(fmt.Sprintf("AI is analyzing your changes. (Model: %s)", *opts.Model)).
 			Action(func() {
-				a.analyzeToChannel(providers, ctx, data, opts, resultChan)
+				a.analyzeToChannel(providers, ctx, data, opts, resultChan, nil)
 			}).
 			Run(); err != nil {
 			return "", err
 		}
 	} else {
-		a.analyzeToChannel(providers, ctx, data, opts, resultChan)
+		a.analyzeToChannel(providers, ctx, data, opts, resultChan, nil)
 	}
 
 	res := <-resultChan
 	if res.err != nil {
-		color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", res.err)
+		if !opts.JSONOutput() {
+			color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", res.err)
+		}
 		return "", res.err
 	}
 
@@ -116,33 +157,34 @@ func (a *AIService) GenerateCommitMessage(
 
 	message := strings.TrimSpace(res.message)
 	if message == "" {
-		return "", fmt.Errorf("no commit messages were generated. try again")
+		return "", Errorf(ErrCodeEmptyMessage, "no commit messages were generated. try again")
 	}
 
-	return message, nil
+	if violations := LintCommitMessage(message, LoadLintRules(*opts.MaxLength)); len(violations) > 0 && !*opts.Quiet {
+		color.New(color.FgYellow).Printf("⚠ Commit message still violates Conventional Commits rules:\n%s\n", FormatLintViolations(violations))
+	}
+
+	// Issue references are added after linting: a template may put them in
+	// the subject, which is not a Conventional Commits header.
+	return ApplyIssueReferences(message, data.Issue)
 }
 
-// analyzeToChannel performs the actual AI analysis and sends result to channel
+// analyzeToChannel performs the actual AI analysis and sends result to channel.
+// A non-nil onDelta switches to the streaming API.
 func (a *AIService) analyzeToChannel(
 	providers []ProviderConfig,
 	ctx context.Context,
 	data *PreCommitData,
 	opts *CommitOptions,
 	resultChan chan analyzeResult,
+	onDelta func(string),
 ) {
-	message, err := a.AnalyzeChanges(
-		providers,
-		ctx,
-		data.Diff,
-		opts.UserContext,
-		&data.RelatedFiles,
-		opts.MaxLength,
-		opts.Language,
-		&data.Issue,
-	)
+	promptData := a.PromptDataFor(data, opts.UserContext, opts.MaxLength, opts.Language)
+	message, err := a.analyzePromptData(providers, ctx, promptData, onDelta)
 	resultChan <- analyzeResult{message: message, err: err}
 }
 
+// GetUserPrompt renders the user prompt template for a commit message request
 func (a *AIService) GetUserPrompt(
 	context *string,
 	diff string,
@@ -151,37 +193,90 @@ func (a *AIService) GetUserPrompt(
 	language *string,
 	issue *string,
 ) (string, error) {
-	if *context != "" {
-		temp := fmt.Sprintf("Use the following context to understand intent: %s", *context)
-		context = &temp
-	} else {
-		*context = ""
-	}
+	return RenderPrompt(PromptUser, newPromptData(diff, files, context, maxLength, language, issue))
+}
 
-	prompt := fmt.Sprintf(
-		`%s
+// BuildCommitPrompts renders the system and user prompts used to generate a
+// commit message for data.
+func (a *AIService) BuildCommitPrompts(data *PromptData) (string, string, error) {
+	system, err := RenderRequestPrompt(PromptSystem, data)
+	if err != nil {
+		return "", "", err
+	}
+	user, err := RenderRequestPrompt(PromptUser, data)
+	if err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
 
-Code diff:
-%s
+// PromptDataFor builds the template data for a commit message generated
+// from data.
+func (a *AIService) PromptDataFor(data *PreCommitData, userContext *string, maxLength *int, language *string) *PromptData {
+	relatedFiles := formatRelatedFiles(data.RelatedFiles)
+	if !data.Detached {
+		return newPromptData(data.Diff, relatedFiles, userContext, maxLength, language, &data.Issue)
+	}
+	promptData := basePromptData(data.Diff, relatedFiles, userContext, maxLength, language, &data.Issue)
+	promptData.Branch = data.Branch
+	promptData.Style = data.Style.PromptSection(LoadLintRules(*maxLength))
+	return promptData
+}
 
-Neighboring files:
-%s
+// newPromptData collects the template data for a commit message request,
+// including the current branch and the style learned from the repository.
+func newPromptData(
+	diff string,
+	files []string,
+	userContext *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+) *PromptData {
+	data := basePromptData(diff, files, userContext, maxLength, language, issue)
+	data.Branch = currentBranch()
+	data.Style = LoadStyleProfile().PromptSection(LoadLintRules(*maxLength))
+	return data
+}
 
-Requirements:
-- Maximum commit message length: %d characters
-- Language: %s`,
-		*context,
-		diff,
-		strings.Join(files, ", "),
-		*maxLength,
-		*language,
-	)
+// basePromptData is newPromptData without anything read from the repository.
+// files are the neighboring files; the changed files are read from diff.
+func basePromptData(
+	diff string,
+	files []string,
+	userContext *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+) *PromptData {
+	data := &PromptData{
+		Diff:         diff,
+		Files:        diffFiles(diff),
+		RelatedFiles: files,
+		Language:     *language,
+		MaxLength:    *maxLength,
+	}
+	if userContext != nil {
+		data.Context = *userContext
+	}
+	if issue != nil {
+		data.Issue = *issue
+		data.IssueDetails = cachedIssueDetails(*issue)
+	}
+	return data
+}
 
-	if *issue != "" {
-		prompt += fmt.Sprintf("\n- Reference issue: %s", *issue)
+// withRequirements appends the language and length requirements and
+// the learned style guidance to a rendered system prompt.
+func withRequirements(systemPrompt string, data *PromptData) string {
+	if data.Language != "english" {
+		systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Generate the commit message in %s language.", data.Language)
 	}
-
-	return prompt, nil
+	systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Keep the commit message under %d characters.", data.MaxLength)
+	if data.Style != "" {
+		systemPrompt += "\n\n" + data.Style
+	}
+	return systemPrompt
 }
 
 // formatRelatedFiles formats a map of directory to files into a slice of strings
@@ -194,10 +289,10 @@ func formatRelatedFiles(dirToFiles map[string]string) []string {
 	return relatedFilesArray
 }
 
-// chatCompleteOnce calls the OpenAI chat completion API on a single client with
+// chatCompleteOnce calls a single provider's chat completion API with
 // internal retry. It is the per-provider primitive used by chatCompleteFallback.
 func chatCompleteOnce(
-	client *openai.Client,
+	client chatClient,
 	ctx context.Context,
 	model string,
 	systemPrompt string,
@@ -205,30 +300,18 @@ func chatCompleteOnce(
 ) (string, error) {
 	var lastErr error
 	for attempt := 0; attempt < 2; attempt++ {
-		resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-			Model: model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: userPrompt,
-				},
-			},
-			Temperature: 0.2,
-			MaxTokens:   1000,
+		content, err := client.complete(ctx, chatRequest{
+			Model:        model,
+			SystemPrompt: systemPrompt,
+			UserPrompt:   userPrompt,
+			Temperature:  0.2,
+			MaxTokens:    1000,
 		})
 		if err != nil {
 			lastErr = err
 			continue
 		}
-		if len(resp.Choices) == 0 {
-			lastErr = fmt.Errorf("no response from AI model")
-			continue
-		}
-		text := strings.TrimSpace(resp.Choices[0].Message.Content)
+		text := strings.TrimSpace(content)
 		if text == "" {
 			lastErr = fmt.Errorf("empty response text from model")
 			continue
@@ -241,6 +324,49 @@ func chatCompleteOnce(
 	return "", lastErr
 }
 
+// chatStreamOnce is the streaming counterpart of chatCompleteOnce. Attempts
+// are only retried while nothing has been emitted; started reports whether
+// any text reached onDelta.
+func chatStreamOnce(
+	client chatClient,
+	ctx context.Context,
+	model string,
+	systemPrompt string,
+	userPrompt string,
+	onDelta func(string),
+) (string, bool, error) {
+	started := false
+	emit := func(delta string) {
+		started = true
+		onDelta(delta)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 2 && !started; attempt++ {
+		content, err := client.stream(ctx, chatRequest{
+			Model:        model,
+			SystemPrompt: systemPrompt,
+			UserPrompt:   userPrompt,
+			Temperature:  0.2,
+			MaxTokens:    1000,
+		}, emit)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		text := strings.TrimSpace(content)
+		if text == "" {
+			lastErr = fmt.Errorf("empty response text from model")
+			continue
+		}
+		return text, started, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("failed to get response from AI model")
+	}
+	return "", started, lastErr
+}
+
 func (a *AIService) AnalyzeChanges(
 	providers []ProviderConfig,
 	ctx context.Context,
@@ -251,29 +377,53 @@ func (a *AIService) AnalyzeChanges(
 	language *string,
 	issue *string,
 ) (string, error) {
-	relatedFilesArray := formatRelatedFiles(*relatedFiles)
+	return a.AnalyzeChangesStream(providers, ctx, diff, userContext, relatedFiles, maxLength, language, issue, nil)
+}
 
-	userPrompt, err := a.GetUserPrompt(userContext, diff, relatedFilesArray, maxLength, language, issue)
+// AnalyzeChangesStream is AnalyzeChanges with incremental output: when
+// onDelta is non-nil the response is streamed and each fragment is passed to
+// it as it arrives. The returned message is the same cleaned-up text.
+func (a *AIService) AnalyzeChangesStream(
+	providers []ProviderConfig,
+	ctx context.Context,
+	diff string,
+	userContext *string,
+	relatedFiles *map[string]string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	onDelta func(string),
+) (string, error) {
+	data := newPromptData(diff, formatRelatedFiles(*relatedFiles), userContext, maxLength, language, issue)
+	return a.analyzePromptData(providers, ctx, data, onDelta)
+}
+
+// analyzePromptData generates a commit message from rendered prompt data,
+// streaming it to onDelta when that is non-nil.
+func (a *AIService) analyzePromptData(
+	providers []ProviderConfig,
+	ctx context.Context,
+	data *PromptData,
+	onDelta func(string),
+) (string, error) {
+	enhancedSystemPrompt, userPrompt, err := a.BuildCommitPrompts(data)
 	if err != nil {
 		return "", err
 	}
 
-	enhancedSystemPrompt := a.systemPrompt
-	if *language != "english" {
-		enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Generate the commit message in %s language.", *language)
-	}
-	enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Keep the commit message under %d characters.", *maxLength)
-	if *issue != "" {
-		enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Reference issue %s in the commit message.", *issue)
+	var result string
+	if onDelta != nil {
+		result, err = chatCompleteStreamFallback(ctx, providers, enhancedSystemPrompt, userPrompt, onDelta)
+	} else {
+		result, err = chatCompleteFallback(ctx, providers, enhancedSystemPrompt, userPrompt)
 	}
-
-	result, err := chatCompleteFallback(ctx, providers, enhancedSystemPrompt, userPrompt)
 	if err != nil {
 		return "", err
 	}
 
 	result = strings.ReplaceAll(result, "```", "")
 	result = strings.TrimSpace(result)
+	result = enforceConventionalCommit(ctx, providers, enhancedSystemPrompt, userPrompt, result, LoadLintRules(data.MaxLength))
 
 	return result, nil
 }
@@ -293,7 +443,17 @@ Here's the code diff:
 		diff,
 	)
 
-	result, err := chatCompleteFallback(ctx, providers, fileSelectionPrompt, prompt)
+	systemPrompt, err := RenderPrompt(PromptFileSelection, &PromptData{
+		Diff:    diff,
+		Files:   diffFiles(diff),
+		Context: *userContext,
+		Branch:  currentBranch(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := chatCompleteFallback(ctx, providers, systemPrompt, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -323,7 +483,7 @@ Here's the code diff:
 	}
 
 	if filesStr == "" {
-		return nil, fmt.Errorf("AI response did not include file list in expected format. Response was: %s", result)
+		return nil, Errorf(ErrCodeInvalidAIResponse, "AI response did not include file list in expected format. Response was: %s", result)
 	}
 
 	files := strings.Split(filesStr, ",")
@@ -342,7 +502,8 @@ Here's the code diff:
 	return validFiles, nil
 }
 
-// SelectFilesAndGenerateCommit combines file selection and commit message generation in a single AI request
+// SelectFilesAndGenerateCommit combines file selection and commit message generation in a single AI request.
+// The returned selection may contain hunk IDs ("path#N") for partially selected files.
 func (a *AIService) SelectFilesAndGenerateCommit(
 	providers []ProviderConfig,
 	ctx context.Context,
@@ -363,40 +524,25 @@ func (a *AIService) SelectFilesAndGenerateCommit(
 	}
 
 	relatedFilesArray := formatRelatedFiles(*opts.RelatedFiles)
-
-	contextStr := ""
-	if opts.UserContext != nil && *opts.UserContext != "" {
-		contextStr = fmt.Sprintf("Use the following context to understand intent: %s\n\n", *opts.UserContext)
+	hunkDiff := ""
+	if opts.HunkDiff != nil {
+		hunkDiff = *opts.HunkDiff
 	}
-
-	prompt := fmt.Sprintf(
-		`%sHere's the code diff:
-%s
-
-Neighboring files:
-%s
-
-Requirements:
-- Maximum commit message length: %d characters
-- Language: %s`,
-		contextStr,
-		diff,
-		strings.Join(relatedFilesArray, ", "),
-		*opts.MaxLength,
-		*opts.Language,
-	)
-
-	if opts.Issue != nil && *opts.Issue != "" {
-		prompt += fmt.Sprintf("\n- Reference issue: %s", *opts.Issue)
+	annotatedDiff := diff
+	if hunkDiff != "" {
+		annotatedDiff = AnnotateDiffHunks(diff)
 	}
 
-	enhancedSystemPrompt := combinedPrompt
-	if *opts.Language != "english" {
-		enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Generate the commit message in %s language.", *opts.Language)
+	data := newPromptData(diff, relatedFilesArray, opts.UserContext, opts.MaxLength, opts.Language, opts.Issue)
+	enhancedSystemPrompt, err := RenderRequestPrompt(PromptCombined, data)
+	if err != nil {
+		return nil, "", err
 	}
-	enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Keep the commit message under %d characters.", *opts.MaxLength)
-	if opts.Issue != nil && *opts.Issue != "" {
-		enhancedSystemPrompt += fmt.Sprintf("\n\nIMPORTANT: Reference issue %s in the commit message.", *opts.Issue)
+	userData := *data
+	userData.Diff = annotatedDiff
+	prompt, err := RenderRequestPrompt(PromptCombinedUser, &userData)
+	if err != nil {
+		return nil, "", err
 	}
 
 	result, err := chatCompleteFallback(ctx, providers, enhancedSystemPrompt, prompt)
@@ -443,7 +589,7 @@ Requirements:
 	}
 
 	if filesStr == "" {
-		return nil, "", fmt.Errorf("AI response did not include file list in expected format. Response was: %s", result)
+		return nil, "", Errorf(ErrCodeInvalidAIResponse, "AI response did not include file list in expected format. Response was: %s", result)
 	}
 
 	files := strings.Split(filesStr, ",")
@@ -458,6 +604,7 @@ Requirements:
 			validFiles = append(validFiles, f)
 		}
 	}
+	validFiles = CollapseHunkSelection(validFiles, hunkDiff)
 
 	// Parse commit message from response
 	var commitMessage string
@@ -491,8 +638,35 @@ Requirements:
 	}
 
 	if commitMessage == "" {
-		return nil, "", fmt.Errorf("AI response did not include commit message in expected format. Response was: %s", result)
+		return nil, "", Errorf(ErrCodeInvalidAIResponse, "AI response did not include commit message in expected format. Response was: %s", result)
+	}
+	commitMessage = a.enforceCommitRules(providers, ctx, commitMessage, diff, relatedFilesArray, opts)
+	if opts.Issue != nil {
+		if commitMessage, err = ApplyIssueReferences(commitMessage, *opts.Issue); err != nil {
+			return nil, "", err
+		}
 	}
 
 	return validFiles, commitMessage, nil
 }
+
+// enforceCommitRules runs a generated message through the same
+// repair-then-re-prompt loop as AnalyzeChanges. Re-prompts use the regular
+// commit prompts with diff, the text the message was first generated from
+// (possibly truncated or summarized).
+func (a *AIService) enforceCommitRules(
+	providers []ProviderConfig,
+	ctx context.Context,
+	message string,
+	diff string,
+	relatedFiles []string,
+	opts *SelectFilesAndGenerateCommitOptions,
+) string {
+	rules := LoadLintRules(*opts.MaxLength)
+	data := newPromptData(diff, relatedFiles, opts.UserContext, opts.MaxLength, opts.Language, opts.Issue)
+	systemPrompt, userPrompt, err := a.BuildCommitPrompts(data)
+	if err != nil {
+		return RepairCommitMessage(message, rules)
+	}
+	return enforceConventionalCommit(ctx, providers, systemPrompt, userPrompt, message, rules)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion        = "2023-06-01"
+)
+
+// anthropicClient speaks the native Anthropic Messages API (/v1/messages).
+// The HTTP client is a field so it can be pointed at an httptest server.
+type anthropicClient struct {
+	key        string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newAnthropicClient(p ProviderConfig) *anthropicClient {
+	baseURL := p.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+	return &anthropicClient{
+		key:        p.Key,
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float32            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+// AnthropicError is the error shape returned by the Messages API:
+// {"type":"error","error":{"type":"...","message":"..."}}.
+type AnthropicError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *AnthropicError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("anthropic: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("anthropic: status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// messagesURL accepts both "https://host" and "https://host/v1" style base URLs.
+func (c *anthropicClient) messagesURL() string {
+	base := strings.TrimRight(c.baseURL, "/")
+	if strings.HasSuffix(base, "/v1") {
+		return base + "/messages"
+	}
+	return base + "/v1/messages"
+}
+
+func (c *anthropicClient) newRequest(ctx context.Context, body any) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.messagesURL(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.key)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	return req, nil
+}
+
+func (c *anthropicClient) request(r chatRequest) anthropicRequest {
+	return anthropicRequest{
+		Model:       r.Model,
+		MaxTokens:   r.MaxTokens,
+		System:      r.SystemPrompt,
+		Messages:    []anthropicMessage{{Role: "user", Content: r.UserPrompt}},
+		Temperature: r.Temperature,
+	}
+}
+
+func (c *anthropicClient) complete(ctx context.Context, r chatRequest) (string, error) {
+	req, err := c.newRequest(ctx, c.request(r))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", parseAnthropicError(resp.StatusCode, body)
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("anthropic: invalid response: %v", err)
+	}
+
+	var text strings.Builder
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String(), nil
+}
+
+// anthropicStreamEvent covers the server-sent event payloads we care about:
+// content_block_delta carries text, error carries the usual error envelope.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *anthropicClient) stream(ctx context.Context, r chatRequest, onDelta func(string)) (string, error) {
+	body := c.request(r)
+	body.Stream = true
+	req, err := c.newRequest(ctx, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return "", parseAnthropicError(resp.StatusCode, errBody)
+	}
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				text.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "error":
+			return text.String(), &AnthropicError{
+				StatusCode: resp.StatusCode,
+				Type:       event.Error.Type,
+				Message:    event.Error.Message,
+			}
+		case "message_stop":
+			return text.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return text.String(), err
+	}
+	return text.String(), nil
+}
+
+func parseAnthropicError(status int, body []byte) error {
+	var envelope struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		return &AnthropicError{
+			StatusCode: status,
+			Type:       envelope.Error.Type,
+			Message:    envelope.Error.Message,
+		}
+	}
+	return &AnthropicError{
+		StatusCode: status,
+		Message:    strings.TrimSpace(string(body)),
+	}
+}
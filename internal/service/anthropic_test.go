@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// anthropicStandIn serves /v1/messages with handler and records the last
+// decoded request body.
+func anthropicStandIn(t *testing.T, handler func(w http.ResponseWriter, req anthropicRequest)) (*httptest.Server, *anthropicRequest) {
+	t.Helper()
+	var last anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("missing auth headers: key=%q version=%q", r.Header.Get("x-api-key"), r.Header.Get("anthropic-version"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&last); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		handler(w, last)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last
+}
+
+// writeSSE writes server-sent events the way the Messages API streams them.
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal([]byte(event), &typed)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+		w.(http.Flusher).Flush()
+	}
+}
+
+func textDelta(text string) string {
+	return fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%q}}`, text)
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	srv, last := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"feat: add "},{"type":"tool_use"},{"type":"text","text":"parser"}],"stop_reason":"end_turn"}`)
+	})
+
+	// Both base URL styles reach /v1/messages.
+	for _, baseURL := range []string{srv.URL, srv.URL + "/v1/"} {
+		client := newAnthropicClient(ProviderConfig{Key: "sk-ant-test", BaseURL: baseURL})
+		text, err := client.complete(context.Background(), chatRequest{
+			Model: "claude-test", SystemPrompt: "system", UserPrompt: "diff", MaxTokens: 100, Temperature: 0.2,
+		})
+		if err != nil {
+			t.Fatalf("complete(%s): %v", baseURL, err)
+		}
+		if text != "feat: add parser" {
+			t.Errorf("text = %q", text)
+		}
+	}
+	if last.Model != "claude-test" || last.System != "system" || last.MaxTokens != 100 || last.Stream {
+		t.Errorf("unexpected request %+v", *last)
+	}
+	if len(last.Messages) != 1 || last.Messages[0].Role != "user" || last.Messages[0].Content != "diff" {
+		t.Errorf("messages = %+v", last.Messages)
+	}
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantType string
+		wantMsg  string
+	}{
+		{"envelope", http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, "authentication_error", "invalid x-api-key"},
+		{"plain", http.StatusBadGateway, "upstream down\n", "", "upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			client := newAnthropicClient(ProviderConfig{Key: "sk-ant-test", BaseURL: srv.URL})
+
+			for _, call := range []func() error{
+				func() error { _, err := client.complete(context.Background(), chatRequest{}); return err },
+				func() error {
+					_, err := client.stream(context.Background(), chatRequest{}, func(string) {})
+					return err
+				},
+			} {
+				var apiErr *AnthropicError
+				if err := call(); !errors.As(err, &apiErr) {
+					t.Fatalf("error = %v, want *AnthropicError", err)
+				}
+				if apiErr.StatusCode != tt.status || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMsg {
+					t.Errorf("error = %+v", apiErr)
+				}
+			}
+		})
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	srv, last := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		writeSSE(w,
+			`{"type":"message_start","message":{"id":"msg_1"}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			textDelta("fix: handle "),
+			textDelta("empty diffs"),
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_stop"}`,
+		)
+	})
+	client := newAnthropicClient(ProviderConfig{Key: "sk-ant-test", BaseURL: srv.URL})
+
+	var deltas []string
+	text, err := client.stream(context.Background(), chatRequest{Model: "claude-test"}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if text != "fix: handle empty diffs" || strings.Join(deltas, "|") != "fix: handle |empty diffs" {
+		t.Errorf("text = %q, deltas = %q", text, deltas)
+	}
+	if !last.Stream {
+		t.Error("request did not ask for a stream")
+	}
+}
+
+func TestAnthropicStreamErrorEvent(t *testing.T) {
+	srv, _ := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		writeSSE(w,
+			textDelta("feat: "),
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+		)
+	})
+	client := newAnthropicClient(ProviderConfig{Key: "sk-ant-test", BaseURL: srv.URL})
+
+	text, err := client.stream(context.Background(), chatRequest{}, func(string) {})
+	var apiErr *AnthropicError
+	if !errors.As(err, &apiErr) || apiErr.Type != "overloaded_error" {
+		t.Fatalf("error = %v, want overloaded_error", err)
+	}
+	if text != "feat: " {
+		t.Errorf("partial text = %q", text)
+	}
+}
+
+func TestStreamFallbackAnthropic(t *testing.T) {
+	viper.SetConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	t.Cleanup(func() {
+		viper.SetConfigFile("")
+		viper.Set("api.last_provider", nil)
+	})
+
+	var failingHits, fallbackHits atomic.Int32
+	failing, _ := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		failingHits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"api_error","message":"boom"}}`)
+	})
+	interrupted, _ := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		writeSSE(w, textDelta("feat: "), `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	})
+	fallback, _ := anthropicStandIn(t, func(w http.ResponseWriter, _ anthropicRequest) {
+		fallbackHits.Add(1)
+		writeSSE(w, textDelta("feat: from fallback"), `{"type":"message_stop"}`)
+	})
+	provider := func(name, baseURL string) ProviderConfig {
+		return ProviderConfig{Name: name, Type: ProviderTypeAnthropic, Key: "sk-ant-test", BaseURL: baseURL, Model: "claude-test"}
+	}
+
+	// A provider that fails before its first token is retried, then skipped.
+	var shown strings.Builder
+	text, err := chatCompleteStreamFallback(context.Background(),
+		[]ProviderConfig{provider("primary", failing.URL), provider("backup", fallback.URL)},
+		"system", "diff", func(d string) { shown.WriteString(d) })
+	if err != nil {
+		t.Fatalf("fallback: %v", err)
+	}
+	if text != "feat: from fallback" || shown.String() != text {
+		t.Errorf("text = %q, shown = %q", text, shown.String())
+	}
+	if failingHits.Load() != 2 {
+		t.Errorf("failing provider hit %d times, want 2 (one retry)", failingHits.Load())
+	}
+	if got := viper.GetString("api.last_provider"); got != "backup" {
+		t.Errorf("api.last_provider = %q, want backup", got)
+	}
+
+	// Once text has been shown, the error is returned without falling back.
+	fallbackHits.Store(0)
+	_, err = chatCompleteStreamFallback(context.Background(),
+		[]ProviderConfig{provider("primary", interrupted.URL), provider("backup", fallback.URL)},
+		"system", "diff", func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "stream interrupted") {
+		t.Errorf("error = %v, want stream interrupted", err)
+	}
+	if ErrorCode(err) != ErrCodeAIRequestFailed {
+		t.Errorf("code = %s, want %s", ErrorCode(err), ErrCodeAIRequestFailed)
+	}
+	if fallbackHits.Load() != 0 {
+		t.Error("fell back after the stream had started")
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:embed branch_prompt.md
+var branchPrompt string
+
+// DefaultBranchPattern is used when branch.pattern is not set. Placeholders
+// whose value is empty are dropped together with their separator.
+const DefaultBranchPattern = "{type}/{issue}-{slug}"
+
+// maxBranchSlugLength caps the slug part of generated branch names.
+const maxBranchSlugLength = 48
+
+var (
+	nonSlugRe          = regexp.MustCompile(`[^a-z0-9]+`)
+	nonRefCharRe       = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	repeatedSeparators = regexp.MustCompile(`([-_.])[-_.]+`)
+)
+
+// BranchSuggestion is the AI's choice of type and slug for a branch.
+type BranchSuggestion struct {
+	Type string `json:"type"`
+	Slug string `json:"slug"`
+}
+
+// SuggestBranch asks the AI for a branch type and slug from a task
+// description or, when description is empty, from diff.
+func (a *AIService) SuggestBranch(
+	providers []ProviderConfig,
+	ctx context.Context,
+	description string,
+	diff string,
+	types []string,
+) (*BranchSuggestion, error) {
+	var prompt string
+	if description != "" {
+		prompt = fmt.Sprintf("Task description:\n%s", description)
+	} else {
+		prompt = fmt.Sprintf("Uncommitted changes:\n%s", diff)
+	}
+	prompt += fmt.Sprintf("\n\nAllowed types: %s", strings.Join(types, ", "))
+
+	result, err := chatCompleteFallback(ctx, providers, branchPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(result, "{")
+	end := strings.LastIndex(result, "}")
+	if start < 0 || end < start {
+		return nil, Errorf(ErrCodeInvalidAIResponse, "AI response did not include a branch name in expected format. Response was: %s", result)
+	}
+	var suggestion BranchSuggestion
+	if err := json.Unmarshal([]byte(result[start:end+1]), &suggestion); err != nil {
+		return nil, Errorf(ErrCodeInvalidAIResponse, "AI response included an invalid branch name: %v. Response was: %s", err, result)
+	}
+
+	suggestion.Type = strings.ToLower(strings.TrimSpace(suggestion.Type))
+	if !slices.Contains(types, suggestion.Type) && len(types) > 0 {
+		suggestion.Type = types[0]
+	}
+	suggestion.Slug = Slugify(suggestion.Slug, maxBranchSlugLength)
+	if suggestion.Slug == "" {
+		return nil, Errorf(ErrCodeInvalidAIResponse, "AI suggested an empty branch name. Response was: %s", result)
+	}
+	return &suggestion, nil
+}
+
+// Slugify lowercases s and joins its words with hyphens, cutting at a word
+// boundary so the result is at most maxLength characters.
+func Slugify(s string, maxLength int) string {
+	slug := strings.Trim(nonSlugRe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	for len(slug) > maxLength {
+		i := strings.LastIndex(slug[:maxLength], "-")
+		if i <= 0 {
+			slug = slug[:maxLength]
+			break
+		}
+		slug = slug[:i]
+	}
+	return slug
+}
+
+// FormatBranchName fills the {type}, {issue} and {slug} placeholders of
+// pattern. Separators around empty placeholders are removed, so
+// "{type}/{issue}-{slug}" without an issue gives "feat/add-login".
+func FormatBranchName(pattern, branchType, issue, slug string) string {
+	name := strings.NewReplacer(
+		"{type}", branchType,
+		"{issue}", strings.Trim(nonRefCharRe.ReplaceAllString(issue, "-"), "-"),
+		"{slug}", slug,
+	).Replace(pattern)
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		segment = strings.Trim(repeatedSeparators.ReplaceAllString(segment, "$1"), "-_.")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ValidateBranchName checks name with git check-ref-format.
+func (g *GitService) ValidateBranchName(name string) error {
+	if output, err := exec.Command("git", "check-ref-format", "--branch", name).CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return Errorf(ErrCodeInvalidArgument, "invalid branch name %q: %s", name, msg)
+	}
+	return nil
+}
+
+// BranchExists reports whether a local branch called name exists.
+func (g *GitService) BranchExists(name string) bool {
+	return exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+name).Run() == nil
+}
+
+// CreateBranch creates name at HEAD and switches to it, carrying uncommitted
+// changes along.
+func (g *GitService) CreateBranch(name string) error {
+	if output, err := exec.Command("git", "switch", "-c", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create branch %s: %v: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetUncommittedDiff returns staged, unstaged and untracked changes.
+func (g *GitService) GetUncommittedDiff() (string, error) {
+	diff, err := g.GetDiffWithUntracked()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(diff), nil
+}
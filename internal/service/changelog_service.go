@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+//go:embed changelog_prompt.md
+var changelogPrompt string
+
+// Changelog formats accepted by changelog --format.
+const (
+	ChangelogFormatMarkdown = "markdown"
+	ChangelogFormatJSON     = "json"
+)
+
+// ChangelogUnreleased is the version heading used when the range does not
+// end at a tag.
+const ChangelogUnreleased = "Unreleased"
+
+// changelogSections maps commit types to Keep a Changelog sections, in
+// display order. Types not listed go to "Other" when all types are included.
+var changelogSections = []struct {
+	Title string
+	Types []string
+}{
+	{"Added", []string{"feat"}},
+	{"Fixed", []string{"fix"}},
+	{"Changed", []string{"perf", "refactor"}},
+	{"Removed", []string{"revert"}},
+}
+
+const (
+	changelogBreakingTitle = "Breaking Changes"
+	changelogOtherTitle    = "Other"
+)
+
+// ChangelogEntry is one commit in a changelog.
+type ChangelogEntry struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Hash        string `json:"hash"`
+	Breaking    bool   `json:"breaking"`
+}
+
+// ChangelogSection groups entries under a Keep a Changelog heading.
+type ChangelogSection struct {
+	Title   string           `json:"title"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// Changelog is the release section built from a range of commits.
+type Changelog struct {
+	Version  string             `json:"version"`
+	Date     string             `json:"date,omitempty"`
+	From     string             `json:"from,omitempty"`
+	To       string             `json:"to"`
+	Sections []ChangelogSection `json:"sections"`
+	Skipped  int                `json:"skipped"` // commits that are not Conventional Commits
+}
+
+// BuildChangelog groups Conventional Commits into changelog sections.
+// Breaking changes are listed first, with the BREAKING CHANGE footer text
+// when there is one. Types without a section (docs, chore, ...) and commits
+// that are not Conventional Commits are left out unless includeAll is set.
+func BuildChangelog(commits []CommitInfo, includeAll bool) *Changelog {
+	changelog := &Changelog{}
+	grouped := make(map[string][]ChangelogEntry)
+	var breaking []ChangelogEntry
+
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		parsed, err := ParseConventionalCommit(commit.Message)
+		if err != nil {
+			changelog.Skipped++
+			if includeAll {
+				grouped[changelogOtherTitle] = append(grouped[changelogOtherTitle], ChangelogEntry{
+					Description: commit.Subject(),
+					Hash:        ShortHash(commit.Hash),
+				})
+			}
+			continue
+		}
+
+		entry := ChangelogEntry{
+			Type:        strings.ToLower(parsed.Type),
+			Scope:       parsed.Scope,
+			Description: parsed.Description,
+			Hash:        ShortHash(commit.Hash),
+			Breaking:    parsed.Breaking,
+		}
+		if parsed.Breaking {
+			note := entry
+			for _, footer := range parsed.Footers {
+				if footer.Token == "BREAKING CHANGE" || footer.Token == "BREAKING-CHANGE" {
+					note.Description = strings.Join(strings.Fields(footer.Value), " ")
+					break
+				}
+			}
+			breaking = append(breaking, note)
+		}
+
+		title := changelogSectionFor(entry.Type)
+		if title == "" {
+			if !includeAll {
+				continue
+			}
+			title = changelogOtherTitle
+		}
+		grouped[title] = append(grouped[title], entry)
+	}
+
+	if len(breaking) > 0 {
+		changelog.Sections = append(changelog.Sections, ChangelogSection{Title: changelogBreakingTitle, Entries: breaking})
+	}
+	for _, section := range changelogSections {
+		if entries := grouped[section.Title]; len(entries) > 0 {
+			changelog.Sections = append(changelog.Sections, ChangelogSection{Title: section.Title, Entries: entries})
+		}
+	}
+	if entries := grouped[changelogOtherTitle]; len(entries) > 0 {
+		changelog.Sections = append(changelog.Sections, ChangelogSection{Title: changelogOtherTitle, Entries: entries})
+	}
+	return changelog
+}
+
+func changelogSectionFor(commitType string) string {
+	for _, section := range changelogSections {
+		for _, t := range section.Types {
+			if t == commitType {
+				return section.Title
+			}
+		}
+	}
+	return ""
+}
+
+// Heading returns the `## [version] - date` line of the release.
+func (c *Changelog) Heading() string {
+	if c.Version == ChangelogUnreleased || c.Date == "" {
+		return fmt.Sprintf("## [%s]", c.Version)
+	}
+	return fmt.Sprintf("## [%s] - %s", c.Version, c.Date)
+}
+
+// Markdown renders the release section in Keep a Changelog style.
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	b.WriteString(c.Heading() + "\n")
+	if len(c.Sections) == 0 {
+		b.WriteString("\nNo notable changes.\n")
+	}
+	for _, section := range c.Sections {
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		for _, entry := range section.Entries {
+			b.WriteString("- ")
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+			fmt.Fprintf(&b, "%s (%s)\n", entry.Description, entry.Hash)
+		}
+	}
+	return b.String()
+}
+
+const changelogPreamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+var changelogReleaseRe = regexp.MustCompile(`(?m)^## \[([^\]]+)\]`)
+
+// PrependChangelog inserts release (a rendered release section) for version
+// into an existing changelog, above the newest release. A section already
+// present for the same version is replaced, so running it twice gives the
+// same file. With supersedeUnreleased (the release covers the latest
+// commits) an Unreleased section is dropped. An empty existing changelog
+// gets the standard preamble.
+func PrependChangelog(existing, version, release string, supersedeUnreleased bool) string {
+	release = strings.TrimSpace(release) + "\n"
+	if strings.TrimSpace(existing) == "" {
+		return changelogPreamble + "\n" + release
+	}
+
+	if start, end, ok := findChangelogRelease(existing, version); ok {
+		rest := existing[end:]
+		if rest != "" {
+			release += "\n"
+		}
+		return existing[:start] + release + rest
+	}
+	if supersedeUnreleased && version != ChangelogUnreleased {
+		if start, end, ok := findChangelogRelease(existing, ChangelogUnreleased); ok {
+			existing = existing[:start] + existing[end:]
+		}
+	}
+
+	// Unreleased stays on top; releases go above the newest release.
+	for _, m := range changelogReleaseRe.FindAllStringSubmatchIndex(existing, -1) {
+		if version == ChangelogUnreleased || existing[m[2]:m[3]] != ChangelogUnreleased {
+			return existing[:m[0]] + release + "\n" + existing[m[0]:]
+		}
+	}
+	return strings.TrimRight(existing, "\n") + "\n\n" + release
+}
+
+// findChangelogRelease locates the section for version, from its heading up
+// to the next release heading or the end of the file.
+func findChangelogRelease(changelog, version string) (int, int, bool) {
+	matches := changelogReleaseRe.FindAllStringSubmatchIndex(changelog, -1)
+	for i, m := range matches {
+		if changelog[m[2]:m[3]] != version {
+			continue
+		}
+		end := len(changelog)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		return m[0], end, true
+	}
+	return 0, 0, false
+}
+
+// PolishChangelog asks the AI to rewrite a rendered release section as
+// human-friendly release notes with the same structure.
+func (a *AIService) PolishChangelog(
+	providers []ProviderConfig,
+	ctx context.Context,
+	markdown string,
+	language string,
+) (string, error) {
+	systemPrompt := changelogPrompt
+	if language != "" && language != "english" {
+		systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Write the release notes in %s language.", language)
+	}
+	result, err := chatCompleteFallback(ctx, providers, systemPrompt, markdown)
+	if err != nil {
+		return "", err
+	}
+	result = strings.TrimSpace(strings.ReplaceAll(result, "```markdown", ""))
+	result = strings.TrimSpace(strings.ReplaceAll(result, "```", ""))
+	if !strings.HasPrefix(result, "## ") {
+		return "", Errorf(ErrCodeInvalidAIResponse, "AI release notes did not start with the release heading. Response was: %s", result)
+	}
+	return result + "\n", nil
+}
+
+// LatestTag returns the most recent tag reachable from rev, or "" when
+// there is none.
+func (g *GitService) LatestTag(rev string) string {
+	output, err := exec.Command("git", "describe", "--tags", "--abbrev=0", rev).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ExactTag returns a tag pointing exactly at rev, or "" when there is none.
+func (g *GitService) ExactTag(rev string) string {
+	output, err := exec.Command("git", "describe", "--tags", "--exact-match", rev).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GetCommitDate returns the committer date of rev as YYYY-MM-DD.
+func (g *GitService) GetCommitDate(rev string) (string, error) {
+	output, err := exec.Command("git", "log", "-1", "--format=%cs", rev, "--").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the date of %s: %v", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
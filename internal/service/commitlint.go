@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+// Subject case policies accepted in lint.subject_case.
+const (
+	SubjectCaseLower = "lower"
+	SubjectCaseAny   = "any"
+)
+
+// DefaultCommitTypes mirrors @commitlint/config-conventional.
+var DefaultCommitTypes = []string{
+	"feat", "fix", "docs", "style", "refactor", "perf", "test", "chore", "ci", "build", "revert",
+}
+
+var (
+	conventionalHeaderRe = regexp.MustCompile(`^(?P<type>[A-Za-z]+)(?:\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?: (?P<description>.*)$`)
+	footerLineRe         = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][A-Za-z0-9-]*)(?:: | #)(.*)$`)
+	messageLabelRe       = regexp.MustCompile(`(?i)^(?:commit message|commit|message)\s*:\s*`)
+)
+
+// CommitFooter is a `Token: value` or `Token #value` trailer.
+type CommitFooter struct {
+	Token string
+	Value string
+}
+
+// ConventionalCommit is a parsed Conventional Commits message.
+type ConventionalCommit struct {
+	Header      string
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Body        string
+	Footers     []CommitFooter
+}
+
+// ParseConventionalCommit parses `<type>[(scope)][!]: <description>` plus an
+// optional body and footers. The footer block is the first paragraph after
+// the header whose first line looks like a trailer, together with everything
+// that follows it.
+func ParseConventionalCommit(message string) (*ConventionalCommit, error) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	lines := strings.Split(message, "\n")
+
+	match := conventionalHeaderRe.FindStringSubmatch(lines[0])
+	if match == nil {
+		return nil, fmt.Errorf("header %q is not in the form <type>[(scope)]: <description>", lines[0])
+	}
+	commit := &ConventionalCommit{
+		Header:      lines[0],
+		Type:        match[1],
+		Scope:       match[2],
+		Breaking:    match[3] == "!",
+		Description: match[4],
+	}
+
+	var body []string
+	inFooters := false
+	paragraphStart := true
+	for _, line := range lines[1:] {
+		if !inFooters && paragraphStart && footerLineRe.MatchString(line) {
+			inFooters = true
+		}
+		paragraphStart = strings.TrimSpace(line) == ""
+
+		if !inFooters {
+			body = append(body, line)
+			continue
+		}
+		if m := footerLineRe.FindStringSubmatch(line); m != nil {
+			commit.Footers = append(commit.Footers, CommitFooter{Token: m[1], Value: m[2]})
+			if m[1] == "BREAKING CHANGE" || m[1] == "BREAKING-CHANGE" {
+				commit.Breaking = true
+			}
+		} else if n := len(commit.Footers); n > 0 && strings.TrimSpace(line) != "" {
+			commit.Footers[n-1].Value += "\n" + line
+		}
+	}
+	commit.Body = strings.TrimSpace(strings.Join(body, "\n"))
+	return commit, nil
+}
+
+// LintRules configures LintCommitMessage, modelled after commitlint rules.
+type LintRules struct {
+	Enabled           bool
+	Types             []string // type-enum
+	Scopes            []string // scope-enum; empty allows any scope
+	SubjectCase       string   // subject-case
+	SubjectFullStop   bool     // subject-full-stop: forbid a trailing period
+	HeaderMaxLength   int      // header-max-length; 0 disables
+	BodyLeadingBlank  bool     // body-leading-blank
+	BodyMaxLineLength int      // body-max-line-length; 0 disables
+	MaxRetries        int      // re-prompts when violations remain after repair
+}
+
+// LoadLintRules reads the lint.* config keys. headerMaxLength is used when
+// lint.header_max_length is not set (normally commit.max_length).
+func LoadLintRules(headerMaxLength int) LintRules {
+	rules := LintRules{
+		Enabled:          true,
+		Types:            DefaultCommitTypes,
+		SubjectCase:      SubjectCaseLower,
+		SubjectFullStop:  true,
+		HeaderMaxLength:  headerMaxLength,
+		BodyLeadingBlank: true,
+		MaxRetries:       1,
+	}
+	if viper.IsSet("lint.enabled") {
+		rules.Enabled = viper.GetBool("lint.enabled")
+	}
+	if types := viper.GetStringSlice("lint.types"); len(types) > 0 {
+		rules.Types = types
+	}
+	rules.Scopes = viper.GetStringSlice("lint.scopes")
+	if viper.IsSet("lint.subject_case") {
+		rules.SubjectCase = viper.GetString("lint.subject_case")
+	}
+	if viper.IsSet("lint.subject_full_stop") {
+		rules.SubjectFullStop = viper.GetBool("lint.subject_full_stop")
+	}
+	if viper.IsSet("lint.header_max_length") {
+		rules.HeaderMaxLength = viper.GetInt("lint.header_max_length")
+	}
+	if viper.IsSet("lint.body_leading_blank") {
+		rules.BodyLeadingBlank = viper.GetBool("lint.body_leading_blank")
+	}
+	if viper.IsSet("lint.body_max_line_length") {
+		rules.BodyMaxLineLength = viper.GetInt("lint.body_max_line_length")
+	}
+	if viper.IsSet("lint.max_retries") {
+		rules.MaxRetries = viper.GetInt("lint.max_retries")
+	}
+	return rules
+}
+
+// LintViolation is one failed rule.
+type LintViolation struct {
+	Rule    string
+	Message string
+}
+
+func (v LintViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// LintCommitMessage checks message against rules and returns every violation.
+func LintCommitMessage(message string, rules LintRules) []LintViolation {
+	if !rules.Enabled {
+		return nil
+	}
+
+	commit, err := ParseConventionalCommit(message)
+	if err != nil {
+		return []LintViolation{{Rule: "header-format", Message: err.Error()}}
+	}
+
+	var violations []LintViolation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, LintViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if commit.Type != strings.ToLower(commit.Type) {
+		add("type-case", "type %q must be lower-case", commit.Type)
+	}
+	if len(rules.Types) > 0 && !slices.Contains(rules.Types, strings.ToLower(commit.Type)) {
+		add("type-enum", "type %q is not one of %s", commit.Type, strings.Join(rules.Types, ", "))
+	}
+	if commit.Scope != "" && len(rules.Scopes) > 0 && !slices.Contains(rules.Scopes, commit.Scope) {
+		add("scope-enum", "scope %q is not one of %s", commit.Scope, strings.Join(rules.Scopes, ", "))
+	}
+
+	description := strings.TrimSpace(commit.Description)
+	if description == "" {
+		add("subject-empty", "description must not be empty")
+	} else {
+		if rules.SubjectCase == SubjectCaseLower && startsWithCapitalizedWord(description) {
+			add("subject-case", "description must start with a lower-case letter")
+		}
+		if rules.SubjectFullStop && strings.HasSuffix(description, ".") {
+			add("subject-full-stop", "description must not end with a period")
+		}
+	}
+
+	if rules.HeaderMaxLength > 0 {
+		if n := utf8.RuneCountInString(commit.Header); n > rules.HeaderMaxLength {
+			add("header-max-length", "header is %d characters, the limit is %d", n, rules.HeaderMaxLength)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	if rules.BodyLeadingBlank && len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add("body-leading-blank", "the body must be separated from the header by a blank line")
+	}
+	if rules.BodyMaxLineLength > 0 {
+		for _, line := range lines[1:] {
+			if n := utf8.RuneCountInString(line); n > rules.BodyMaxLineLength {
+				add("body-max-line-length", "body line is %d characters, the limit is %d", n, rules.BodyMaxLineLength)
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// RepairCommitMessage deterministically fixes what it safely can: preamble
+// text before the header ("Here is your commit:"), labels such as
+// "Commit message:", surrounding quotes, type case, a capitalised
+// description, a trailing period and a missing blank line before the body.
+func RepairCommitMessage(message string, rules LintRules) string {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	if !rules.Enabled || message == "" {
+		return message
+	}
+	lines := strings.Split(message, "\n")
+
+	// Drop any preamble before the first line that looks like a header.
+	for i, line := range lines {
+		candidate := strings.TrimSpace(line)
+		if !conventionalHeaderRe.MatchString(candidate) {
+			candidate = cleanHeaderCandidate(line)
+		}
+		if conventionalHeaderRe.MatchString(candidate) {
+			lines[i] = candidate
+			lines = lines[i:]
+			break
+		}
+	}
+
+	header := lines[0]
+	if match := conventionalHeaderRe.FindStringSubmatch(header); match != nil {
+		typ, scope, bang, description := match[1], match[2], match[3], strings.TrimSpace(match[4])
+		typ = strings.ToLower(typ)
+		if rules.SubjectFullStop {
+			description = strings.TrimRight(description, ". ")
+		}
+		if rules.SubjectCase == SubjectCaseLower && startsWithCapitalizedWord(description) {
+			r, size := utf8.DecodeRuneInString(description)
+			description = string(unicode.ToLower(r)) + description[size:]
+		}
+		header = typ
+		if scope != "" {
+			header += "(" + scope + ")"
+		}
+		header += bang + ": " + description
+	}
+	lines[0] = header
+
+	if rules.BodyLeadingBlank && len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		lines = append([]string{lines[0], ""}, lines[1:]...)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// cleanHeaderCandidate strips decoration models like to put around a header.
+func cleanHeaderCandidate(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.Trim(line, "`\"'*")
+	line = messageLabelRe.ReplaceAllString(line, "")
+	return strings.TrimSpace(strings.Trim(line, "`\"'*"))
+}
+
+// startsWithCapitalizedWord is true for "Add x" but false for acronyms and
+// identifiers such as "API", "README" or "JSONParser".
+func startsWithCapitalizedWord(s string) bool {
+	first, size := utf8.DecodeRuneInString(s)
+	if !unicode.IsUpper(first) {
+		return false
+	}
+	second, _ := utf8.DecodeRuneInString(s[size:])
+	return !unicode.IsUpper(second) && !unicode.IsDigit(second)
+}
+
+// FormatLintViolations renders violations as a bullet list for prompts and
+// warnings.
+func FormatLintViolations(violations []LintViolation) string {
+	var b strings.Builder
+	for _, v := range violations {
+		fmt.Fprintf(&b, "- %s\n", v)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// enforceConventionalCommit repairs message and, while violations remain,
+// re-prompts the model with the specific violations up to rules.MaxRetries
+// times. The best attempt is returned even if it still has violations; the
+// caller decides whether to warn about them.
+func enforceConventionalCommit(
+	ctx context.Context,
+	providers []ProviderConfig,
+	systemPrompt string,
+	userPrompt string,
+	message string,
+	rules LintRules,
+) string {
+	if !rules.Enabled {
+		return message
+	}
+
+	message = RepairCommitMessage(message, rules)
+	violations := LintCommitMessage(message, rules)
+	for attempt := 0; attempt < rules.MaxRetries && len(violations) > 0; attempt++ {
+		retryPrompt := fmt.Sprintf(
+			"%s\n\nYour previous commit message was:\n%s\n\nIt violates these rules:\n%s\n\nRespond with only the corrected commit message.",
+			userPrompt,
+			message,
+			FormatLintViolations(violations),
+		)
+		result, err := chatCompleteFallback(ctx, providers, systemPrompt, retryPrompt)
+		if err != nil {
+			break
+		}
+		candidate := RepairCommitMessage(strings.ReplaceAll(result, "```", ""), rules)
+		candidateViolations := LintCommitMessage(candidate, rules)
+		if len(candidateViolations) <= len(violations) {
+			message, violations = candidate, candidateViolations
+		}
+	}
+	return message
+}
@@ -1,7 +1,11 @@
 package service
 
 var (
-	DefaultModel        = "gpt-3.5-turbo"
-	DefaultBaseUrl      = ""
-	DefaultMaxDiffLines = 500
+	DefaultModel          = "gpt-3.5-turbo"
+	DefaultBaseUrl        = ""
+	DefaultMaxDiffLines   = 500
+	DefaultTokenBudget    = 12000
+	DefaultSummaryWorkers = 4
+	// DefaultStyleSampleSize is how many recent commits style profiling reads
+	DefaultStyleSampleSize = 50
 )
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Error codes reported by --output json. Scripts match on these, so existing
+// codes must never change meaning.
+const (
+	ErrCodeGitNotInstalled   = "git_not_installed"
+	ErrCodeNotARepository    = "not_a_repository"
+	ErrCodeNoChanges         = "no_changes"
+	ErrCodeNoProvider        = "no_provider"
+	ErrCodeInvalidConfig     = "invalid_config"
+	ErrCodeInvalidArgument   = "invalid_argument"
+	ErrCodeAIRequestFailed   = "ai_request_failed"
+	ErrCodeInvalidAIResponse = "invalid_ai_response"
+	ErrCodeEmptyMessage      = "empty_message"
+	ErrCodeSecretsDetected   = "secrets_detected"
+	ErrCodeCommitFailed      = "commit_failed"
+	ErrCodePushFailed        = "push_failed"
+	ErrCodeRemoteFailed      = "remote_failed"
+	ErrCodePullRequestFailed = "pull_request_failed"
+	ErrCodeCancelled         = "cancelled"
+	ErrCodeUnknown           = "unknown"
+)
+
+// CodedError attaches a stable error code to an error.
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string { return e.Err.Error() }
+func (e *CodedError) Unwrap() error { return e.Err }
+
+// WithCode wraps err with code. It returns nil for a nil err and keeps the
+// innermost code when err already has one.
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// Errorf is fmt.Errorf with a code attached.
+func Errorf(code string, format string, args ...interface{}) error {
+	return &CodedError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorCode returns the code attached to err, or ErrCodeUnknown.
+func ErrorCode(err error) string {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return ErrCodeUnknown
+}
+
+// WriteJSONError writes err to w as {"error": {"code": ..., "message": ...}}.
+func WriteJSONError(w io.Writer, err error) {
+	writeJSONProblem(w, "error", err)
+}
+
+// WriteJSONWarning writes a non-fatal problem to w as
+// {"warning": {"code": ..., "message": ...}}.
+func WriteJSONWarning(w io.Writer, err error) {
+	writeJSONProblem(w, "warning", err)
+}
+
+func writeJSONProblem(w io.Writer, kind string, err error) {
+	doc := map[string]interface{}{
+		kind: map[string]string{
+			"code":    ErrorCode(err),
+			"message": err.Error(),
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(doc)
+}
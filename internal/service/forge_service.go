@@ -0,0 +1,833 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Forge types accepted in [[forges]].
+const (
+	ForgeGitHub    = "github"
+	ForgeGitLab    = "gitlab"
+	ForgeGitea     = "gitea"
+	ForgeForgejo   = "forgejo"
+	ForgeBitbucket = "bitbucket"
+)
+
+// DefaultBitbucketAPIURL is the Bitbucket Cloud REST API.
+const DefaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// forgeTokenEnv is where each API-based forge looks for a token that is not
+// set in [[forges]].
+var forgeTokenEnv = map[string]string{
+	ForgeGitLab:    "GITLAB_TOKEN",
+	ForgeGitea:     "GITEA_TOKEN",
+	ForgeForgejo:   "GITEA_TOKEN",
+	ForgeBitbucket: "BITBUCKET_TOKEN",
+}
+
+// forgeTokenHosts are the hosts forgeTokenEnv tokens are sent to without
+// being declared in [[forges]]. A host that only looks like a forge by name
+// never receives them.
+var forgeTokenHosts = map[string]string{
+	ForgeGitLab:    "gitlab.com",
+	ForgeForgejo:   "codeberg.org",
+	ForgeBitbucket: "bitbucket.org",
+}
+
+var remoteURLRe = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// RemoteInfo is the host and project path of a remote URL.
+type RemoteInfo struct {
+	Host string // e.g. gitlab.example.com
+	Path string // e.g. group/sub/repo
+}
+
+// ParseRemoteURL reads the host and path from an https, ssh or scp-like
+// remote URL.
+func ParseRemoteURL(remoteURL string) (RemoteInfo, bool) {
+	m := remoteURLRe.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if m == nil {
+		return RemoteInfo{}, false
+	}
+	return RemoteInfo{Host: strings.ToLower(m[1]), Path: m[2]}, true
+}
+
+// GetRemoteInfo returns the host and project path of remoteName.
+func (g *GitService) GetRemoteInfo(remoteName string) (RemoteInfo, error) {
+	output, err := exec.Command("git", "remote", "get-url", remoteName).Output()
+	if err != nil {
+		return RemoteInfo{}, Errorf(ErrCodeRemoteFailed, "failed to get URL of remote '%s': %v", remoteName, err)
+	}
+	info, ok := ParseRemoteURL(string(output))
+	if !ok {
+		return RemoteInfo{}, Errorf(ErrCodeRemoteFailed, "could not parse URL of remote '%s': %s", remoteName, strings.TrimSpace(string(output)))
+	}
+	return info, nil
+}
+
+// ForgeConfig is one [[forges]] entry, mapping a host to a forge type.
+type ForgeConfig struct {
+	Host     string `mapstructure:"host"`
+	Type     string `mapstructure:"type"`
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username"` // Bitbucket app passwords only
+	APIURL   string `mapstructure:"api_url"`
+}
+
+// PullRequestOptions describes a pull or merge request to open.
+type PullRequestOptions struct {
+	Title     string
+	Body      string
+	Head      string // source branch, already pushed
+	Base      string // target branch; "" means the project's default branch
+	Draft     bool
+	Labels    []string
+	Assignees []string
+	Quiet     bool
+}
+
+// PullRequest is an open pull request found on a forge.
+type PullRequest struct {
+	ID    string // number, or iid on GitLab
+	URL   string
+	Title string
+	Body  string
+	Base  string // target branch
+}
+
+// Forge opens pull requests on a code hosting service.
+type Forge interface {
+	// Noun is what the forge calls a pull request.
+	Noun() string
+	CreatePullRequest(ctx context.Context, opts PullRequestOptions) (string, error)
+	// FindPullRequest returns the open pull request from head, or nil.
+	FindPullRequest(ctx context.Context, head string) (*PullRequest, error)
+	// UpdatePullRequest replaces the title and body of pr and returns its URL.
+	UpdatePullRequest(ctx context.Context, pr *PullRequest, title, body string, quiet bool) (string, error)
+}
+
+// Markers around the part of a pull request body that opencommit owns.
+// Updates replace only what is between them.
+const (
+	PullRequestBodyStart = "<!-- opencommit:start -->"
+	PullRequestBodyEnd   = "<!-- opencommit:end -->"
+)
+
+// WrapPullRequestBody puts a generated body between the opencommit markers.
+func WrapPullRequestBody(body string) string {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return PullRequestBodyStart + "\n" + PullRequestBodyEnd
+	}
+	return PullRequestBodyStart + "\n" + body + "\n" + PullRequestBodyEnd
+}
+
+// MergePullRequestBody replaces the generated block of existing with body,
+// keeping whatever was written around it. A body without markers is kept
+// whole below the new block.
+func MergePullRequestBody(existing, body string) string {
+	block := WrapPullRequestBody(body)
+	start := strings.Index(existing, PullRequestBodyStart)
+	if start >= 0 {
+		if end := strings.Index(existing[start:], PullRequestBodyEnd); end >= 0 {
+			end += start + len(PullRequestBodyEnd)
+			return existing[:start] + block + existing[end:]
+		}
+	}
+	existing = strings.TrimSpace(existing)
+	if existing == "" {
+		return block
+	}
+	return block + "\n\n" + existing
+}
+
+// keepTitlePrefix carries a draft marker such as "Draft: " over from the old
+// title, since some forges keep the draft state in the title.
+func keepTitlePrefix(oldTitle, title string, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(strings.ToLower(oldTitle), strings.ToLower(prefix)) &&
+			!strings.HasPrefix(strings.ToLower(title), strings.ToLower(prefix)) {
+			return oldTitle[:len(prefix)] + title
+		}
+	}
+	return title
+}
+
+// DetectForge picks the forge for remote from [[forges]], falling back to
+// well-known hosts and hosts named after their forge.
+func DetectForge(remote RemoteInfo) (Forge, error) {
+	cfg := ForgeConfig{Host: remote.Host}
+
+	var entries []ForgeConfig
+	if err := viper.UnmarshalKey("forges", &entries); err != nil {
+		return nil, Errorf(ErrCodeInvalidConfig, "invalid [[forges]] config: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Host, remote.Host) {
+			cfg, found = entry, true
+			break
+		}
+	}
+	if !found {
+		switch {
+		case remote.Host == "github.com":
+			cfg.Type = ForgeGitHub
+		case remote.Host == "bitbucket.org":
+			cfg.Type = ForgeBitbucket
+		case remote.Host == "codeberg.org", strings.Contains(remote.Host, "forgejo"):
+			cfg.Type = ForgeForgejo
+		case strings.Contains(remote.Host, "gitlab"):
+			cfg.Type = ForgeGitLab
+		case strings.Contains(remote.Host, "gitea"):
+			cfg.Type = ForgeGitea
+		default:
+			return nil, Errorf(
+				ErrCodeInvalidConfig,
+				"unknown forge for %s: add it to [[forges]] with host = %q and a type",
+				remote.Host, remote.Host,
+			)
+		}
+	}
+
+	forgeType := strings.ToLower(cfg.Type)
+	if cfg.Token == "" && forgeTokenEnv[forgeType] != "" && (found || forgeTokenHosts[forgeType] == remote.Host) {
+		cfg.Token = os.Getenv(forgeTokenEnv[forgeType])
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch forgeType {
+	case ForgeGitHub:
+		return &GitHubForge{Host: remote.Host}, nil
+	case ForgeGitLab:
+		if cfg.APIURL == "" {
+			cfg.APIURL = "https://" + remote.Host + "/api/v4"
+		}
+		return &GitLabForge{
+			APIURL:  strings.TrimRight(cfg.APIURL, "/"),
+			Token:   cfg.Token,
+			Project: remote.Path,
+			Client:  client,
+		}, nil
+	case ForgeGitea, ForgeForgejo:
+		if cfg.APIURL == "" {
+			cfg.APIURL = "https://" + remote.Host + "/api/v1"
+		}
+		return &GiteaForge{
+			APIURL:  strings.TrimRight(cfg.APIURL, "/"),
+			Token:   cfg.Token,
+			Project: remote.Path,
+			Client:  client,
+		}, nil
+	case ForgeBitbucket:
+		if cfg.APIURL == "" {
+			cfg.APIURL = DefaultBitbucketAPIURL
+		}
+		return &BitbucketForge{
+			APIURL:   strings.TrimRight(cfg.APIURL, "/"),
+			Token:    cfg.Token,
+			Username: cfg.Username,
+			Project:  remote.Path,
+			Client:   client,
+		}, nil
+	default:
+		return nil, Errorf(
+			ErrCodeInvalidConfig,
+			"invalid forge type %q for %s (use %s, %s, %s, %s or %s)",
+			cfg.Type, remote.Host, ForgeGitHub, ForgeGitLab, ForgeGitea, ForgeForgejo, ForgeBitbucket,
+		)
+	}
+}
+
+// GitHubForge opens pull requests with the gh CLI.
+type GitHubForge struct {
+	Host string
+}
+
+func (f *GitHubForge) Noun() string { return "pull request" }
+
+func (f *GitHubForge) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (string, error) {
+	args := []string{"pr", "create", "--title", opts.Title, "--body", opts.Body}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	if opts.Base != "" {
+		args = append(args, "--base", opts.Base)
+	}
+	for _, label := range opts.Labels {
+		args = append(args, "--label", label)
+	}
+	for _, assignee := range opts.Assignees {
+		args = append(args, "--assignee", assignee)
+	}
+
+	output, err := runForgeCLI(f.command(ctx, args...), opts.Quiet)
+	if err != nil {
+		return "", Errorf(ErrCodePullRequestFailed, "failed to create pull request: %v", err)
+	}
+	return pullRequestURL(output), nil
+}
+
+func (f *GitHubForge) FindPullRequest(ctx context.Context, head string) (*PullRequest, error) {
+	var prs []struct {
+		Number      int    `json:"number"`
+		URL         string `json:"url"`
+		Title       string `json:"title"`
+		Body        string `json:"body"`
+		BaseRefName string `json:"baseRefName"`
+	}
+	args := []string{"pr", "list", "--head", head, "--state", "open", "--json", "number,url,title,body,baseRefName", "--limit", "1"}
+	if err := readForgeCLI(f.command(ctx, args...), &prs); err != nil {
+		return nil, Errorf(ErrCodePullRequestFailed, "failed to look up pull requests: %v", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	pr := prs[0]
+	return &PullRequest{ID: strconv.Itoa(pr.Number), URL: pr.URL, Title: pr.Title, Body: pr.Body, Base: pr.BaseRefName}, nil
+}
+
+func (f *GitHubForge) UpdatePullRequest(ctx context.Context, pr *PullRequest, title, body string, quiet bool) (string, error) {
+	args := []string{"pr", "edit", pr.ID, "--title", title, "--body", body}
+	if _, err := runForgeCLI(f.command(ctx, args...), quiet); err != nil {
+		return "", Errorf(ErrCodePullRequestFailed, "failed to update pull request: %v", err)
+	}
+	return pr.URL, nil
+}
+
+// command builds a gh command aimed at the forge's host.
+func (f *GitHubForge) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	if f.Host != "github.com" {
+		cmd.Env = append(os.Environ(), "GH_HOST="+f.Host)
+	}
+	return cmd
+}
+
+// GitLabForge opens merge requests through the REST API when a token is
+// configured, otherwise with the glab CLI.
+type GitLabForge struct {
+	APIURL  string
+	Token   string
+	Project string
+	Client  *http.Client
+}
+
+func (f *GitLabForge) Noun() string { return "merge request" }
+
+func (f *GitLabForge) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (string, error) {
+	if f.Token == "" {
+		if _, err := exec.LookPath("glab"); err == nil {
+			return f.createWithCLI(ctx, opts)
+		}
+		return "", Errorf(
+			ErrCodePullRequestFailed,
+			"no GitLab token: set token in [[forges]], or GITLAB_TOKEN for gitlab.com and hosts in [[forges]], or install glab",
+		)
+	}
+	return f.createWithAPI(ctx, opts)
+}
+
+// gitlabMergeRequest is the part of a GitLab merge request opencommit reads.
+type gitlabMergeRequest struct {
+	IID          int    `json:"iid"`
+	WebURL       string `json:"web_url"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	TargetBranch string `json:"target_branch"`
+}
+
+func (f *GitLabForge) FindPullRequest(ctx context.Context, head string) (*PullRequest, error) {
+	var mrs []gitlabMergeRequest
+	if f.Token == "" {
+		if _, err := exec.LookPath("glab"); err != nil {
+			return nil, Errorf(
+				ErrCodePullRequestFailed,
+				"no GitLab token: set token in [[forges]], or GITLAB_TOKEN for gitlab.com and hosts in [[forges]], or install glab",
+			)
+		}
+		cmd := exec.CommandContext(ctx, "glab", "mr", "list", "--source-branch", head, "--output", "json")
+		if err := readForgeCLI(cmd, &mrs); err != nil {
+			return nil, Errorf(ErrCodePullRequestFailed, "failed to look up merge requests: %v", err)
+		}
+	} else {
+		path := "/projects/" + url.PathEscape(f.Project) + "/merge_requests?state=opened&source_branch=" + url.QueryEscape(head)
+		if err := f.request(ctx, http.MethodGet, path, nil, &mrs); err != nil {
+			return nil, err
+		}
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	mr := mrs[0]
+	return &PullRequest{
+		ID:    strconv.Itoa(mr.IID),
+		URL:   mr.WebURL,
+		Title: mr.Title,
+		Body:  mr.Description,
+		Base:  mr.TargetBranch,
+	}, nil
+}
+
+func (f *GitLabForge) UpdatePullRequest(ctx context.Context, pr *PullRequest, title, body string, quiet bool) (string, error) {
+	title = keepTitlePrefix(pr.Title, title, "Draft: ", "Draft:", "[Draft] ", "(Draft) ")
+	if f.Token == "" {
+		cmd := exec.CommandContext(ctx, "glab", "mr", "update", pr.ID, "--title", title, "--description", body)
+		if _, err := runForgeCLI(cmd, quiet); err != nil {
+			return "", Errorf(ErrCodePullRequestFailed, "failed to update merge request: %v", err)
+		}
+		return pr.URL, nil
+	}
+
+	var mr gitlabMergeRequest
+	path := "/projects/" + url.PathEscape(f.Project) + "/merge_requests/" + pr.ID
+	payload := map[string]string{"title": title, "description": body}
+	if err := f.request(ctx, http.MethodPut, path, payload, &mr); err != nil {
+		return "", err
+	}
+	if !quiet {
+		fmt.Println(mr.WebURL)
+	}
+	return mr.WebURL, nil
+}
+
+func (f *GitLabForge) createWithCLI(ctx context.Context, opts PullRequestOptions) (string, error) {
+	args := []string{"mr", "create", "--yes", "--title", opts.Title, "--description", opts.Body, "--source-branch", opts.Head}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	if opts.Base != "" {
+		args = append(args, "--target-branch", opts.Base)
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--label", strings.Join(opts.Labels, ","))
+	}
+	if len(opts.Assignees) > 0 {
+		args = append(args, "--assignee", strings.Join(opts.Assignees, ","))
+	}
+
+	output, err := runForgeCLI(exec.CommandContext(ctx, "glab", args...), opts.Quiet)
+	if err != nil {
+		return "", Errorf(ErrCodePullRequestFailed, "failed to create merge request: %v", err)
+	}
+	return pullRequestURL(output), nil
+}
+
+func (f *GitLabForge) createWithAPI(ctx context.Context, opts PullRequestOptions) (string, error) {
+	project := url.PathEscape(f.Project)
+
+	base := opts.Base
+	if base == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := f.request(ctx, http.MethodGet, "/projects/"+project, nil, &info); err != nil {
+			return "", err
+		}
+		base = info.DefaultBranch
+	}
+
+	var assigneeIDs []int
+	for _, username := range opts.Assignees {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		if err := f.request(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+			return "", err
+		}
+		if len(users) == 0 {
+			return "", Errorf(ErrCodeInvalidArgument, "unknown GitLab user %q", username)
+		}
+		assigneeIDs = append(assigneeIDs, users[0].ID)
+	}
+
+	title := opts.Title
+	if opts.Draft {
+		title = "Draft: " + title
+	}
+	payload := map[string]interface{}{
+		"source_branch": opts.Head,
+		"target_branch": base,
+		"title":         title,
+		"description":   opts.Body,
+	}
+	if len(opts.Labels) > 0 {
+		payload["labels"] = strings.Join(opts.Labels, ",")
+	}
+	if len(assigneeIDs) > 0 {
+		payload["assignee_ids"] = assigneeIDs
+	}
+
+	var mr struct {
+		WebURL string `json:"web_url"`
+	}
+	if err := f.request(ctx, http.MethodPost, "/projects/"+project+"/merge_requests", payload, &mr); err != nil {
+		return "", err
+	}
+	if !opts.Quiet {
+		fmt.Println(mr.WebURL)
+	}
+	return mr.WebURL, nil
+}
+
+// request calls the GitLab API and decodes the JSON response into out.
+func (f *GitLabForge) request(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	headers := map[string]string{"PRIVATE-TOKEN": f.Token, "Content-Type": "application/json"}
+	if err := forgeRequest(ctx, f.Client, method, f.APIURL+path, headers, payload, out); err != nil {
+		return Errorf(ErrCodePullRequestFailed, "GitLab API: %v", err)
+	}
+	return nil
+}
+
+// giteaPageSize is the page size asked of Gitea list endpoints. Servers may
+// cap it lower, so paging stops on an empty page rather than a short one.
+const giteaPageSize = 50
+
+// GiteaForge opens pull requests on Gitea and Forgejo through the REST API.
+type GiteaForge struct {
+	APIURL  string
+	Token   string
+	Project string // owner/repo
+	Client  *http.Client
+}
+
+func (f *GiteaForge) Noun() string { return "pull request" }
+
+func (f *GiteaForge) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (string, error) {
+	if f.Token == "" {
+		return "", Errorf(ErrCodePullRequestFailed, "no Gitea token: set token in [[forges]], or GITEA_TOKEN for codeberg.org and hosts in [[forges]]")
+	}
+	repo := "/repos/" + escapePath(f.Project)
+
+	base := opts.Base
+	if base == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := f.request(ctx, http.MethodGet, repo, nil, &info); err != nil {
+			return "", err
+		}
+		base = info.DefaultBranch
+	}
+
+	// Labels are referenced by ID, so look them up by name.
+	var labelIDs []int64
+	if len(opts.Labels) > 0 {
+		var labels []giteaLabel
+		for page := 1; ; page++ {
+			var batch []giteaLabel
+			if err := f.request(ctx, http.MethodGet, fmt.Sprintf("%s/labels?limit=%d&page=%d", repo, giteaPageSize, page), nil, &batch); err != nil {
+				return "", err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			labels = append(labels, batch...)
+		}
+		for _, name := range opts.Labels {
+			found := false
+			for _, label := range labels {
+				if strings.EqualFold(label.Name, name) {
+					labelIDs = append(labelIDs, label.ID)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return "", Errorf(ErrCodeInvalidArgument, "unknown label %q in %s", name, f.Project)
+			}
+		}
+	}
+
+	// Gitea has no draft flag; a WIP prefix marks the pull request as
+	// work in progress.
+	title := opts.Title
+	if opts.Draft {
+		title = "WIP: " + title
+	}
+	payload := map[string]interface{}{
+		"head":  opts.Head,
+		"base":  base,
+		"title": title,
+		"body":  opts.Body,
+	}
+	if len(labelIDs) > 0 {
+		payload["labels"] = labelIDs
+	}
+	if len(opts.Assignees) > 0 {
+		payload["assignees"] = opts.Assignees
+	}
+
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := f.request(ctx, http.MethodPost, repo+"/pulls", payload, &pr); err != nil {
+		return "", err
+	}
+	if !opts.Quiet {
+		fmt.Println(pr.HTMLURL)
+	}
+	return pr.HTMLURL, nil
+}
+
+// giteaLabel is a Gitea repository label.
+type giteaLabel struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// giteaPullRequest is the part of a Gitea pull request opencommit reads.
+type giteaPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Head    struct {
+		Ref  string `json:"ref"`
+		Repo *struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (f *GiteaForge) FindPullRequest(ctx context.Context, head string) (*PullRequest, error) {
+	if f.Token == "" {
+		return nil, Errorf(ErrCodePullRequestFailed, "no Gitea token: set token in [[forges]], or GITEA_TOKEN for codeberg.org and hosts in [[forges]]")
+	}
+	// The list cannot be filtered by head branch, so page through the open
+	// ones. Pull requests from forks with a branch of the same name are
+	// skipped.
+	for page := 1; ; page++ {
+		var prs []giteaPullRequest
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&limit=%d&page=%d", escapePath(f.Project), giteaPageSize, page)
+		if err := f.request(ctx, http.MethodGet, path, nil, &prs); err != nil {
+			return nil, err
+		}
+		if len(prs) == 0 {
+			return nil, nil
+		}
+		for _, pr := range prs {
+			if pr.Head.Ref != head || pr.Head.Repo == nil || !strings.EqualFold(pr.Head.Repo.FullName, f.Project) {
+				continue
+			}
+			return &PullRequest{
+				ID:    strconv.Itoa(pr.Number),
+				URL:   pr.HTMLURL,
+				Title: pr.Title,
+				Body:  pr.Body,
+				Base:  pr.Base.Ref,
+			}, nil
+		}
+	}
+}
+
+func (f *GiteaForge) UpdatePullRequest(ctx context.Context, pr *PullRequest, title, body string, quiet bool) (string, error) {
+	title = keepTitlePrefix(pr.Title, title, "WIP: ", "WIP:", "[WIP] ")
+	var updated giteaPullRequest
+	path := "/repos/" + escapePath(f.Project) + "/pulls/" + pr.ID
+	payload := map[string]string{"title": title, "body": body}
+	if err := f.request(ctx, http.MethodPatch, path, payload, &updated); err != nil {
+		return "", err
+	}
+	if !quiet {
+		fmt.Println(updated.HTMLURL)
+	}
+	return updated.HTMLURL, nil
+}
+
+// request calls the Gitea API and decodes the JSON response into out.
+func (f *GiteaForge) request(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	headers := map[string]string{"Authorization": "token " + f.Token, "Content-Type": "application/json"}
+	if err := forgeRequest(ctx, f.Client, method, f.APIURL+path, headers, payload, out); err != nil {
+		return Errorf(ErrCodePullRequestFailed, "Gitea API: %v", err)
+	}
+	return nil
+}
+
+// BitbucketForge opens pull requests on Bitbucket Cloud through the REST
+// API. Token is an access token, or an app password when Username is set.
+type BitbucketForge struct {
+	APIURL   string
+	Token    string
+	Username string
+	Project  string // workspace/repo_slug
+	Client   *http.Client
+}
+
+func (f *BitbucketForge) Noun() string { return "pull request" }
+
+func (f *BitbucketForge) CreatePullRequest(ctx context.Context, opts PullRequestOptions) (string, error) {
+	if f.Token == "" {
+		return "", Errorf(ErrCodePullRequestFailed, "no Bitbucket token: set token in [[forges]], or BITBUCKET_TOKEN for bitbucket.org and hosts in [[forges]]")
+	}
+	if len(opts.Labels) > 0 || len(opts.Assignees) > 0 {
+		return "", Errorf(ErrCodeInvalidArgument, "Bitbucket pull requests have no labels or assignees")
+	}
+
+	payload := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Body,
+		"source":      map[string]interface{}{"branch": map[string]string{"name": opts.Head}},
+		"draft":       opts.Draft,
+	}
+	// Without a destination Bitbucket uses the repository's main branch.
+	if opts.Base != "" {
+		payload["destination"] = map[string]interface{}{"branch": map[string]string{"name": opts.Base}}
+	}
+
+	var pr bitbucketPullRequest
+	if err := f.request(ctx, http.MethodPost, "/repositories/"+escapePath(f.Project)+"/pullrequests", payload, &pr); err != nil {
+		return "", err
+	}
+	if !opts.Quiet {
+		fmt.Println(pr.Links.HTML.Href)
+	}
+	return pr.Links.HTML.Href, nil
+}
+
+// bitbucketPullRequest is the part of a Bitbucket pull request opencommit
+// reads.
+type bitbucketPullRequest struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (f *BitbucketForge) FindPullRequest(ctx context.Context, head string) (*PullRequest, error) {
+	if f.Token == "" {
+		return nil, Errorf(ErrCodePullRequestFailed, "no Bitbucket token: set token in [[forges]], or BITBUCKET_TOKEN for bitbucket.org and hosts in [[forges]]")
+	}
+	var page struct {
+		Values []bitbucketPullRequest `json:"values"`
+	}
+	query := url.QueryEscape(fmt.Sprintf(`source.branch.name = %q AND source.repository.full_name = %q`, head, f.Project))
+	path := "/repositories/" + escapePath(f.Project) + "/pullrequests?state=OPEN&q=" + query
+	if err := f.request(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	pr := page.Values[0]
+	return &PullRequest{
+		ID:    strconv.Itoa(pr.ID),
+		URL:   pr.Links.HTML.Href,
+		Title: pr.Title,
+		Body:  pr.Description,
+		Base:  pr.Destination.Branch.Name,
+	}, nil
+}
+
+func (f *BitbucketForge) UpdatePullRequest(ctx context.Context, pr *PullRequest, title, body string, quiet bool) (string, error) {
+	var updated bitbucketPullRequest
+	path := "/repositories/" + escapePath(f.Project) + "/pullrequests/" + pr.ID
+	payload := map[string]string{"title": title, "description": body}
+	if err := f.request(ctx, http.MethodPut, path, payload, &updated); err != nil {
+		return "", err
+	}
+	if !quiet {
+		fmt.Println(updated.Links.HTML.Href)
+	}
+	return updated.Links.HTML.Href, nil
+}
+
+// request calls the Bitbucket API and decodes the JSON response into out.
+func (f *BitbucketForge) request(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	auth := "Bearer " + f.Token
+	if f.Username != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(f.Username+":"+f.Token))
+	}
+	headers := map[string]string{"Authorization": auth, "Content-Type": "application/json"}
+	if err := forgeRequest(ctx, f.Client, method, f.APIURL+path, headers, payload, out); err != nil {
+		return Errorf(ErrCodePullRequestFailed, "Bitbucket API: %v", err)
+	}
+	return nil
+}
+
+// forgeRequest encodes payload, if any, as JSON and sends it with apiRequest.
+func forgeRequest(
+	ctx context.Context,
+	client *http.Client,
+	method string,
+	endpoint string,
+	headers map[string]string,
+	payload interface{},
+	out interface{},
+) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	return apiRequest(ctx, client, method, endpoint, headers, body, out)
+}
+
+// escapePath escapes each segment of a slash-separated project path.
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// readForgeCLI runs gh or glab and decodes its JSON output into out.
+func readForgeCLI(cmd *exec.Cmd, out interface{}) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(output, out)
+}
+
+// runForgeCLI runs gh or glab, passing its stderr through unless quiet, and
+// returns its stdout, which is also printed unless quiet.
+func runForgeCLI(cmd *exec.Cmd, quiet bool) (string, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if !quiet {
+		cmd.Stderr = os.Stderr
+	}
+	err := cmd.Run()
+	if !quiet {
+		fmt.Print(stdout.String())
+	}
+	return stdout.String(), err
+}
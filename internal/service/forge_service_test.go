@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fakeAPI is an httptest stand-in for a forge API. Routes are keyed by
+// "METHOD /path" and receive the decoded JSON body, if any.
+type fakeAPI struct {
+	t      *testing.T
+	routes map[string]func(r *http.Request, body map[string]interface{}) (int, string)
+
+	mu       sync.Mutex
+	requests []string // "METHOD /path?query"
+	bodies   map[string]map[string]interface{}
+	auth     []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{
+		t:      t,
+		routes: make(map[string]func(*http.Request, map[string]interface{}) (int, string)),
+		bodies: make(map[string]map[string]interface{}),
+	}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (a *fakeAPI) handle(route string, fn func(r *http.Request, body map[string]interface{}) (int, string)) {
+	a.routes[route] = fn
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	raw, _ := io.ReadAll(r.Body)
+	var body map[string]interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &body); err != nil {
+			a.t.Errorf("invalid JSON body for %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+	route := r.Method + " " + r.URL.EscapedPath()
+
+	a.mu.Lock()
+	a.requests = append(a.requests, r.Method+" "+r.URL.RequestURI())
+	a.bodies[route] = body
+	a.auth = append(a.auth, r.Header.Get("Authorization")+r.Header.Get("PRIVATE-TOKEN"))
+	a.mu.Unlock()
+
+	fn, ok := a.routes[route]
+	if !ok {
+		a.t.Errorf("unexpected request %s", r.Method+" "+r.URL.RequestURI())
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"not found"}`)
+		return
+	}
+	status, response := fn(r, body)
+	w.WriteHeader(status)
+	fmt.Fprint(w, response)
+}
+
+func (a *fakeAPI) body(route string) map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.bodies[route]
+}
+
+func reply(status int, response string) func(*http.Request, map[string]interface{}) (int, string) {
+	return func(*http.Request, map[string]interface{}) (int, string) { return status, response }
+}
+
+func TestGitLabForgeAPI(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("GET /api/v4/projects/group%2Fsub%2Frepo", reply(200, `{"default_branch":"main"}`))
+	api.handle("GET /api/v4/users", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		if r.URL.Query().Get("username") == "alice" {
+			return 200, `[{"id":7}]`
+		}
+		return 200, `[]`
+	})
+	api.handle("POST /api/v4/projects/group%2Fsub%2Frepo/merge_requests",
+		reply(201, `{"iid":3,"web_url":"https://gitlab.test/group/sub/repo/-/merge_requests/3"}`))
+	api.handle("GET /api/v4/projects/group%2Fsub%2Frepo/merge_requests", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		if r.URL.Query().Get("source_branch") != "feat" || r.URL.Query().Get("state") != "opened" {
+			return 200, `[]`
+		}
+		return 200, `[{"iid":3,"web_url":"https://gitlab.test/mr/3","title":"Draft: feat: old","description":"old","target_branch":"develop"}]`
+	})
+	api.handle("PUT /api/v4/projects/group%2Fsub%2Frepo/merge_requests/3", reply(200, `{"iid":3,"web_url":"https://gitlab.test/mr/3"}`))
+
+	forge := &GitLabForge{APIURL: srv.URL + "/api/v4", Token: "glpat", Project: "group/sub/repo", Client: srv.Client()}
+
+	url, err := forge.CreatePullRequest(ctx, PullRequestOptions{
+		Title: "feat: add x", Body: "body", Head: "feat", Draft: true,
+		Labels: []string{"a", "b"}, Assignees: []string{"alice"}, Quiet: true,
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if url != "https://gitlab.test/group/sub/repo/-/merge_requests/3" {
+		t.Errorf("url = %q", url)
+	}
+	created := api.body("POST /api/v4/projects/group%2Fsub%2Frepo/merge_requests")
+	if created["title"] != "Draft: feat: add x" || created["target_branch"] != "main" || created["labels"] != "a,b" {
+		t.Errorf("unexpected merge request payload: %v", created)
+	}
+	if ids, _ := created["assignee_ids"].([]interface{}); len(ids) != 1 || ids[0] != float64(7) {
+		t.Errorf("assignee_ids = %v", created["assignee_ids"])
+	}
+
+	if _, err := forge.CreatePullRequest(ctx, PullRequestOptions{Title: "x", Head: "feat", Base: "main", Assignees: []string{"bob"}, Quiet: true}); err == nil {
+		t.Error("expected an error for an unknown assignee")
+	}
+
+	pr, err := forge.FindPullRequest(ctx, "feat")
+	if err != nil || pr == nil {
+		t.Fatalf("FindPullRequest = %v, %v", pr, err)
+	}
+	if pr.ID != "3" || pr.Base != "develop" {
+		t.Errorf("found %+v", pr)
+	}
+	if none, err := forge.FindPullRequest(ctx, "other"); none != nil || err != nil {
+		t.Errorf("FindPullRequest(other) = %v, %v", none, err)
+	}
+
+	if _, err := forge.UpdatePullRequest(ctx, pr, "feat: new", "new body", true); err != nil {
+		t.Fatalf("UpdatePullRequest: %v", err)
+	}
+	updated := api.body("PUT /api/v4/projects/group%2Fsub%2Frepo/merge_requests/3")
+	if updated["title"] != "Draft: feat: new" || updated["description"] != "new body" {
+		t.Errorf("unexpected update payload: %v", updated)
+	}
+	for _, auth := range api.auth {
+		if auth != "glpat" {
+			t.Errorf("request sent with credentials %q", auth)
+		}
+	}
+}
+
+func TestGiteaForgeAPI(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("GET /api/v1/repos/owner/repo", reply(200, `{"default_branch":"main"}`))
+	api.handle("GET /api/v1/repos/owner/repo/labels", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			return 200, `[{"id":1,"name":"bug"}]`
+		case "2":
+			return 200, `[{"id":2,"name":"UI"}]`
+		}
+		return 200, `[]`
+	})
+	api.handle("POST /api/v1/repos/owner/repo/pulls", reply(201, `{"number":4,"html_url":"https://gitea.test/owner/repo/pulls/4"}`))
+	api.handle("GET /api/v1/repos/owner/repo/pulls", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			// A fork's branch of the same name must not match.
+			return 200, `[{"number":1,"head":{"ref":"feat","repo":{"full_name":"someone/repo"}},"base":{"ref":"main"}}]`
+		case "2":
+			return 200, `[{"number":9,"html_url":"https://gitea.test/owner/repo/pulls/9","title":"WIP: feat: old","body":"b","head":{"ref":"feat","repo":{"full_name":"owner/repo"}},"base":{"ref":"release"}}]`
+		}
+		return 200, `[]`
+	})
+	api.handle("PATCH /api/v1/repos/owner/repo/pulls/9", reply(201, `{"number":9,"html_url":"https://gitea.test/owner/repo/pulls/9"}`))
+
+	forge := &GiteaForge{APIURL: srv.URL + "/api/v1", Token: "tok", Project: "owner/repo", Client: srv.Client()}
+
+	url, err := forge.CreatePullRequest(ctx, PullRequestOptions{
+		Title: "feat: add x", Body: "body", Head: "feat", Draft: true,
+		Labels: []string{"bug", "ui"}, Assignees: []string{"alice"}, Quiet: true,
+	})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if url != "https://gitea.test/owner/repo/pulls/4" {
+		t.Errorf("url = %q", url)
+	}
+	created := api.body("POST /api/v1/repos/owner/repo/pulls")
+	if created["title"] != "WIP: feat: add x" || created["base"] != "main" || created["head"] != "feat" {
+		t.Errorf("unexpected pull request payload: %v", created)
+	}
+	if labels, _ := created["labels"].([]interface{}); len(labels) != 2 || labels[1] != float64(2) {
+		t.Errorf("labels = %v, want IDs from both pages", created["labels"])
+	}
+
+	if _, err := forge.CreatePullRequest(ctx, PullRequestOptions{Title: "x", Head: "feat", Base: "main", Labels: []string{"nope"}, Quiet: true}); err == nil {
+		t.Error("expected an error for an unknown label")
+	}
+
+	pr, err := forge.FindPullRequest(ctx, "feat")
+	if err != nil || pr == nil {
+		t.Fatalf("FindPullRequest = %v, %v", pr, err)
+	}
+	if pr.ID != "9" || pr.Base != "release" {
+		t.Errorf("found %+v, want #9 from this repository", pr)
+	}
+	if none, err := forge.FindPullRequest(ctx, "other"); none != nil || err != nil {
+		t.Errorf("FindPullRequest(other) = %v, %v", none, err)
+	}
+
+	if _, err := forge.UpdatePullRequest(ctx, pr, "feat: new", "new body", true); err != nil {
+		t.Fatalf("UpdatePullRequest: %v", err)
+	}
+	updated := api.body("PATCH /api/v1/repos/owner/repo/pulls/9")
+	if updated["title"] != "WIP: feat: new" || updated["body"] != "new body" {
+		t.Errorf("unexpected update payload: %v", updated)
+	}
+	for _, auth := range api.auth {
+		if auth != "token tok" {
+			t.Errorf("request sent with credentials %q", auth)
+		}
+	}
+}
+
+func TestBitbucketForgeAPI(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("POST /2.0/repositories/ws/repo/pullrequests", func(r *http.Request, body map[string]interface{}) (int, string) {
+		if body["title"] == "empty" {
+			return 400, `{"type":"error","error":{"message":"There are no changes to be pulled"}}`
+		}
+		return 201, `{"id":5,"links":{"html":{"href":"https://bitbucket.test/ws/repo/pull-requests/5"}}}`
+	})
+	api.handle("GET /2.0/repositories/ws/repo/pullrequests", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		if !strings.Contains(r.URL.Query().Get("q"), `source.branch.name = "feat"`) ||
+			!strings.Contains(r.URL.Query().Get("q"), `source.repository.full_name = "ws/repo"`) {
+			return 200, `{"values":[]}`
+		}
+		return 200, `{"values":[{"id":5,"title":"feat: old","description":"d","destination":{"branch":{"name":"main"}},"links":{"html":{"href":"https://bitbucket.test/pr/5"}}}]}`
+	})
+	api.handle("PUT /2.0/repositories/ws/repo/pullrequests/5", reply(200, `{"id":5,"links":{"html":{"href":"https://bitbucket.test/pr/5"}}}`))
+
+	forge := &BitbucketForge{APIURL: srv.URL + "/2.0", Token: "app-password", Username: "me", Project: "ws/repo", Client: srv.Client()}
+
+	url, err := forge.CreatePullRequest(ctx, PullRequestOptions{Title: "feat: add x", Body: "body", Head: "feat", Base: "main", Draft: true, Quiet: true})
+	if err != nil {
+		t.Fatalf("CreatePullRequest: %v", err)
+	}
+	if url != "https://bitbucket.test/ws/repo/pull-requests/5" {
+		t.Errorf("url = %q", url)
+	}
+	created := api.body("POST /2.0/repositories/ws/repo/pullrequests")
+	if created["draft"] != true || created["description"] != "body" {
+		t.Errorf("unexpected pull request payload: %v", created)
+	}
+	if api.auth[0] != "Basic bWU6YXBwLXBhc3N3b3Jk" {
+		t.Errorf("auth = %q, want basic auth with the app password", api.auth[0])
+	}
+
+	_, err = forge.CreatePullRequest(ctx, PullRequestOptions{Title: "empty", Head: "feat", Quiet: true})
+	if err == nil || !strings.Contains(err.Error(), "There are no changes to be pulled") {
+		t.Errorf("error = %v, want the API's message", err)
+	}
+	if _, err := forge.CreatePullRequest(ctx, PullRequestOptions{Title: "x", Head: "feat", Labels: []string{"a"}, Quiet: true}); err == nil {
+		t.Error("expected an error for labels")
+	}
+
+	pr, err := forge.FindPullRequest(ctx, "feat")
+	if err != nil || pr == nil {
+		t.Fatalf("FindPullRequest = %v, %v", pr, err)
+	}
+	if pr.ID != "5" || pr.Base != "main" {
+		t.Errorf("found %+v", pr)
+	}
+	if none, err := forge.FindPullRequest(ctx, "other"); none != nil || err != nil {
+		t.Errorf("FindPullRequest(other) = %v, %v", none, err)
+	}
+
+	if _, err := forge.UpdatePullRequest(ctx, pr, "feat: new", "new body", true); err != nil {
+		t.Fatalf("UpdatePullRequest: %v", err)
+	}
+	updated := api.body("PUT /2.0/repositories/ws/repo/pullrequests/5")
+	if updated["title"] != "feat: new" || updated["description"] != "new body" {
+		t.Errorf("unexpected update payload: %v", updated)
+	}
+}
+
+func TestDetectForgeEnvTokens(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "gl-env")
+	t.Setenv("GITEA_TOKEN", "gt-env")
+	t.Setenv("BITBUCKET_TOKEN", "bb-env")
+	viper.Set("forges", []map[string]interface{}{
+		{"host": "git.corp", "type": "gitea"},
+		{"host": "code.corp", "type": "gitlab", "token": "own"},
+	})
+	t.Cleanup(func() { viper.Set("forges", nil) })
+
+	tests := []struct {
+		host  string
+		token string
+	}{
+		{"gitlab.com", "gl-env"},
+		{"gitlab.example-fork.net", ""},
+		{"codeberg.org", "gt-env"},
+		{"gitea.example.net", ""},
+		{"bitbucket.org", "bb-env"},
+		{"git.corp", "gt-env"},
+		{"code.corp", "own"},
+	}
+	for _, tt := range tests {
+		forge, err := DetectForge(RemoteInfo{Host: tt.host, Path: "a/b"})
+		if err != nil {
+			t.Fatalf("DetectForge(%s): %v", tt.host, err)
+		}
+		var token string
+		switch f := forge.(type) {
+		case *GitLabForge:
+			token = f.Token
+		case *GiteaForge:
+			token = f.Token
+		case *BitbucketForge:
+			token = f.Token
+		}
+		if token != tt.token {
+			t.Errorf("%s: token = %q, want %q", tt.host, token, tt.token)
+		}
+	}
+}
+
+func TestMergePullRequestBody(t *testing.T) {
+	tests := []struct {
+		existing string
+		want     string
+	}{
+		{"", "<!-- opencommit:start -->\nnew\n<!-- opencommit:end -->"},
+		{
+			"Notes\n\n<!-- opencommit:start -->\nold\n<!-- opencommit:end -->\n\nCloses #1",
+			"Notes\n\n<!-- opencommit:start -->\nnew\n<!-- opencommit:end -->\n\nCloses #1",
+		},
+		{"hand written", "<!-- opencommit:start -->\nnew\n<!-- opencommit:end -->\n\nhand written"},
+	}
+	for _, tt := range tests {
+		if got := MergePullRequestBody(tt.existing, "new"); got != tt.want {
+			t.Errorf("MergePullRequestBody(%q) = %q, want %q", tt.existing, got, tt.want)
+		}
+	}
+}
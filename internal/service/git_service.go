@@ -2,7 +2,9 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,7 +16,9 @@ import (
 	"github.com/fatih/color"
 )
 
-type GitService struct{}
+type GitService struct {
+	detached bool
+}
 
 var (
 	instance *GitService
@@ -29,6 +33,13 @@ func NewGitService() *GitService {
 	return instance
 }
 
+// Detach makes the repository around the working directory invisible to
+// lookups that only add context: GetRepoRoot and GetRemoteName fail, so no
+// .opencommit.toml, .opencommitignore, prompt directory or remote is used.
+func (g *GitService) Detach() {
+	g.detached = true
+}
+
 // TruncateLargeDiffs splits a multi-file diff by `diff --git` headers and
 // truncates each per-file section that exceeds maxLines, replacing the
 // remainder with a single marker line. Pass <= 0 to disable.
@@ -37,29 +48,78 @@ func TruncateLargeDiffs(diff string, maxLines int) string {
 		return diff
 	}
 
-	const sep = "diff --git "
+	prefix, sections := SplitDiffSections(diff)
+	if sections == nil {
+		return truncateBlock(diff, maxLines)
+	}
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	for i, block := range sections {
+		out.WriteString(truncateBlock(block, maxLines))
+		if i < len(sections)-1 && !strings.HasSuffix(block, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+const diffSectionSep = "diff --git "
+
+// SplitDiffSections splits a multi-file diff into per-file sections, each
+// starting with its `diff --git` header. Any text before the first header is
+// returned as prefix. sections is nil when the diff has no headers at all.
+func SplitDiffSections(diff string) (string, []string) {
 	rest := diff
 	var prefix string
-	if !strings.HasPrefix(rest, sep) {
-		if idx := strings.Index(rest, "\n"+sep); idx >= 0 {
-			prefix = rest[:idx+1]
-			rest = rest[idx+1:]
-		} else {
-			return truncateBlock(diff, maxLines)
+	if !strings.HasPrefix(rest, diffSectionSep) {
+		idx := strings.Index(rest, "\n"+diffSectionSep)
+		if idx < 0 {
+			return diff, nil
 		}
+		prefix = rest[:idx+1]
+		rest = rest[idx+1:]
 	}
 
-	parts := strings.Split(rest, sep)
-	var out strings.Builder
-	out.WriteString(prefix)
-	for i, p := range parts {
+	var sections []string
+	for _, p := range strings.Split(rest, diffSectionSep) {
 		if p == "" {
 			continue
 		}
-		block := sep + p
-		out.WriteString(truncateBlock(block, maxLines))
-		if i < len(parts)-1 && !strings.HasSuffix(block, "\n") {
-			out.WriteString("\n")
+		sections = append(sections, diffSectionSep+p)
+	}
+	return prefix, sections
+}
+
+// DiffSectionPath returns the post-image path ("b/..." side) named in a
+// section's `diff --git` header.
+func DiffSectionPath(section string) string {
+	header, _, _ := strings.Cut(section, "\n")
+	header = strings.TrimPrefix(header, diffSectionSep)
+	if idx := strings.LastIndex(header, " b/"); idx >= 0 {
+		return header[idx+3:]
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[len(fields)-1], "b/")
+}
+
+// FilterDiffSections keeps the sections of diff whose path is one of paths
+// or lies under one of them, like a `git diff -- <paths>` pathspec.
+func FilterDiffSections(diff string, paths []string) string {
+	prefix, sections := SplitDiffSections(diff)
+	var out strings.Builder
+	out.WriteString(prefix)
+	for _, section := range sections {
+		file := DiffSectionPath(section)
+		for _, p := range paths {
+			p = strings.TrimSuffix(p, "/")
+			if file == p || strings.HasPrefix(file, p+"/") {
+				out.WriteString(section)
+				break
+			}
 		}
 	}
 	return out.String()
@@ -78,7 +138,7 @@ func truncateBlock(block string, maxLines int) string {
 
 func (g *GitService) VerifyGitInstallation() error {
 	if err := exec.Command("git", "--version").Run(); err != nil {
-		return fmt.Errorf("git is not installed. %v", err)
+		return Errorf(ErrCodeGitNotInstalled, "git is not installed. %v", err)
 	}
 
 	return nil
@@ -86,7 +146,8 @@ func (g *GitService) VerifyGitInstallation() error {
 
 func (g *GitService) VerifyGitRepository() error {
 	if err := exec.Command("git", "rev-parse", "--show-toplevel").Run(); err != nil {
-		return fmt.Errorf(
+		return Errorf(
+			ErrCodeNotARepository,
 			"the current directory must be a git repository. %v",
 			err,
 		)
@@ -188,12 +249,19 @@ func (g *GitService) parseGitPorcelain(output []byte) ([]string, map[string]stri
 	return files, fileStatus, nil
 }
 
-// GetDiffWithUntracked generates a diff that includes both tracked and untracked files
+// GetDiffWithUntracked generates a diff that includes both tracked and untracked files.
+// Tracked changes are diffed against HEAD, staged or not, so the result is
+// what is left to stage after ResetStaged.
 func (g *GitService) GetDiffWithUntracked() (string, error) {
 	var diffParts []string
 
+	base, err := g.diffBase()
+	if err != nil {
+		return "", err
+	}
+
 	// Get diff for tracked files
-	diffCmd := exec.Command("git", "diff", "--diff-algorithm=minimal")
+	diffCmd := exec.Command("git", "diff", "--diff-algorithm=minimal", base)
 	diffOutput, err := diffCmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get tracked files diff: %v", err)
@@ -261,7 +329,7 @@ func (g *GitService) CommitChanges(message string, quiet *bool) error {
 		cmd.Stderr = os.Stderr
 	}
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to commit changes. %v", err)
+		return Errorf(ErrCodeCommitFailed, "failed to commit changes. %v", err)
 	}
 
 	return nil
@@ -274,17 +342,23 @@ func (g *GitService) PushChanges(quiet *bool) error {
 		cmd.Stderr = os.Stderr
 	}
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to push changes. %v", err)
+		return Errorf(ErrCodePushFailed, "failed to push changes. %v", err)
 	}
 
 	return nil
 }
 
-func (g *GitService) GetLastCommitMessages(count int) ([]string, error) {
-	// Command to get only commit messages
+// GetLastCommitMessages returns the full messages of the last count
+// non-merge commits reachable from rev (HEAD when empty), newest first
+func (g *GitService) GetLastCommitMessages(count int, rev string) ([]string, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
 	cmd := exec.Command("git", "log",
-		"--pretty=format:%s", // %s formats only the commit message
-		"-n", fmt.Sprintf("%d", count))
+		"--no-merges",
+		"--format=%B%x1e", // full message, record-separated
+		"-n", fmt.Sprintf("%d", count),
+		rev, "--")
 
 	var out bytes.Buffer
 	var stderr bytes.Buffer
@@ -296,11 +370,11 @@ func (g *GitService) GetLastCommitMessages(count int) ([]string, error) {
 		return nil, fmt.Errorf("git log error: %v: %s", err, stderr.String())
 	}
 
-	// Split output into lines and filter empty ones
-	messages := strings.Split(out.String(), "\n")
+	// Split output into messages and filter empty ones
+	messages := strings.Split(out.String(), "\x1e")
 	result := make([]string, 0, len(messages))
 	for _, msg := range messages {
-		if msg != "" {
+		if msg = strings.TrimSpace(msg); msg != "" {
 			result = append(result, msg)
 		}
 	}
@@ -308,6 +382,17 @@ func (g *GitService) GetLastCommitMessages(count int) ([]string, error) {
 	return result, nil
 }
 
+// ResolveRevision returns the commit hash rev points at
+func (g *GitService) ResolveRevision(rev string) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DetectIssueFromBranch returns the issues found in the current branch name
+// with the [issue] config, comma-separated.
 func (g *GitService) DetectIssueFromBranch() (string, error) {
 	cmd := exec.Command("git", "branch", "--show-current")
 	output, err := cmd.Output()
@@ -315,32 +400,11 @@ func (g *GitService) DetectIssueFromBranch() (string, error) {
 		return "", fmt.Errorf("failed to get current branch: %v", err)
 	}
 
-	branchName := strings.TrimSpace(string(output))
-
-	// Common patterns for issue detection in branch names
-	patterns := []string{
-		`(?i)([A-Z]+-\d+)`, // GEN-123, ELI-1220 (case-insensitive)
-		`#(\d+)`,           // #123
-		`(\d+)-`,           // 123-feature
-		`-(\d+)-`,          // feature-123-description
-		`issue-(\d+)`,      // issue-123
-		`fix-(\d+)`,        // fix-123
-		`feat-(\d+)`,       // feat-123
-		`bug-(\d+)`,        // bug-123
-	}
-
-	for i, pattern := range patterns {
-		if matches := regexp.MustCompile(pattern).FindStringSubmatch(branchName); len(matches) > 1 {
-			result := matches[1]
-			// Convert to uppercase for the first pattern (issue identifiers like GEN-123)
-			if i == 0 {
-				result = strings.ToUpper(result)
-			}
-			return result, nil
-		}
+	cfg, err := LoadIssueConfig()
+	if err != nil {
+		return "", err
 	}
-
-	return "", nil
+	return strings.Join(cfg.ExtractIssues(strings.TrimSpace(string(output))), ", "), nil
 }
 
 func (g *GitService) CommitChangesWithOptions(message string, quiet *bool, noVerify *bool) error {
@@ -355,7 +419,7 @@ func (g *GitService) CommitChangesWithOptions(message string, quiet *bool, noVer
 		cmd.Stderr = os.Stderr
 	}
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to commit changes. %v", err)
+		return Errorf(ErrCodeCommitFailed, "failed to commit changes. %v", err)
 	}
 
 	return nil
@@ -369,58 +433,65 @@ func (g *GitService) DetectAndPrepareChanges(opts *CommitOptions) (*PreCommitDat
 		}
 	}
 
-	filesChan := make(chan []string, 1)
-	diffChan := make(chan string, 1)
+	var files []string
+	var diff string
+	detect := func() {
+		// If auto-select is enabled, get all changes (not just staged)
+		if *opts.AutoSelect {
+			// Get all changes in working directory (not just staged)
+			allChanges, err := g.GetAllChanges()
+			if err != nil {
+				return
+			}
 
-	if err := spinner.New().
-		Title("Detecting changes").
-		Action(func() {
-			var files []string
-			var diff string
-			var err error
-
-			// If auto-select is enabled, get all changes (not just staged)
-			if *opts.AutoSelect {
-				// Get all changes in working directory (not just staged)
-				allChanges, err := g.GetAllChanges()
-				if err != nil {
-					filesChan <- []string{}
-					diffChan <- ""
-					return
-				}
+			// Get full diff of all changes including untracked files
+			allDiff, err := g.GetDiffWithUntracked()
+			if err != nil {
+				return
+			}
 
-				// Get full diff of all changes including untracked files
-				diff, err = g.GetDiffWithUntracked()
-				if err != nil {
-					filesChan <- []string{}
-					diffChan <- ""
-					return
-				}
+			files, diff = allChanges, allDiff
+			return
+		}
 
-				files = allChanges
-			} else {
-				// For normal flow, get only staged changes
-				files, diff, err = g.DetectDiffChanges()
-				if err != nil {
-					filesChan <- []string{}
-					diffChan <- ""
-					return
-				}
-			}
+		// For normal flow, get only staged changes
+		stagedFiles, stagedDiff, err := g.DetectDiffChanges()
+		if err != nil {
+			return
+		}
+		files, diff = stagedFiles, stagedDiff
+	}
 
-			filesChan <- files
-			diffChan <- diff
-		}).
+	if *opts.Quiet {
+		detect()
+	} else if err := spinner.New().
+		Title("Detecting changes").
+		Action(detect).
 		Run(); err != nil {
 		return nil, err
 	}
 
-	files, diff := <-filesChan, <-diffChan
+	fullDiff := diff
+	diff, ignored := OmitIgnoredFiles(diff)
+	if len(ignored) > 0 && !*opts.Quiet {
+		color.New(color.Faint).Printf("Omitted from prompt: %s\n", strings.Join(ignored, ", "))
+	}
+	var secretReport io.Writer = os.Stderr
+	if opts.JSONOutput() {
+		secretReport = nil
+	}
+	diff, secrets, err := GuardDiffSecretsTo(secretReport, diff)
+	if err != nil {
+		return nil, err
+	}
 
-	if opts.MaxDiffLines != nil && *opts.MaxDiffLines > 0 {
+	truncated := false
+	summarize := opts.Summarize != nil && *opts.Summarize
+	if !summarize && opts.MaxDiffLines != nil && *opts.MaxDiffLines > 0 {
 		original := diff
 		diff = TruncateLargeDiffs(diff, *opts.MaxDiffLines)
-		if !*opts.Quiet && diff != original {
+		truncated = diff != original
+		if !*opts.Quiet && truncated {
 			color.New(color.FgYellow).Printf(
 				"⚠ Diff truncated to %d lines per file to save tokens\n",
 				*opts.MaxDiffLines,
@@ -430,11 +501,13 @@ func (g *GitService) DetectAndPrepareChanges(opts *CommitOptions) (*PreCommitDat
 
 	if len(files) == 0 {
 		if *opts.AutoSelect {
-			return nil, fmt.Errorf(
+			return nil, Errorf(
+				ErrCodeNoChanges,
 				"no changes found in working directory",
 			)
 		} else {
-			return nil, fmt.Errorf(
+			return nil, Errorf(
+				ErrCodeNoChanges,
 				"no staged changes found. stage your changes manually, or automatically stage all changes with the `--all` flag, or use the `--auto` flag to let AI select changes",
 			)
 		}
@@ -446,7 +519,10 @@ func (g *GitService) DetectAndPrepareChanges(opts *CommitOptions) (*PreCommitDat
 	issue := *opts.Issue
 	if issue == "" {
 		detectedIssue, err := g.DetectIssueFromBranch()
-		if err == nil && detectedIssue != "" {
+		if err != nil {
+			return nil, err
+		}
+		if detectedIssue != "" {
 			issue = detectedIssue
 			if !*opts.Quiet {
 				color.New(color.FgCyan).Printf("Auto-detected issue: %s\n", detectedIssue)
@@ -456,9 +532,13 @@ func (g *GitService) DetectAndPrepareChanges(opts *CommitOptions) (*PreCommitDat
 
 	return &PreCommitData{
 		Files:        files,
+		FullDiff:     fullDiff,
 		Diff:         diff,
 		RelatedFiles: relatedFiles,
 		Issue:        issue,
+		Ignored:      ignored,
+		Secrets:      secrets,
+		Truncated:    truncated,
 	}, nil
 }
 
@@ -485,6 +565,31 @@ func (g *GitService) getRelatedFiles(files []string) map[string]string {
 	return relatedFiles
 }
 
+// GetHeadCommit returns the full hash of HEAD, or "" in a repository
+// without commits
+func (g *GitService) GetHeadCommit() (string, error) {
+	if err := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+		return "", nil
+	}
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRepoRoot returns the absolute path of the working tree root
+func (g *GitService) GetRepoRoot() (string, error) {
+	if g.detached {
+		return "", fmt.Errorf("repository lookups are disabled")
+	}
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find repository root: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // ResetStaged resets the staged area, unstaging all files
 func (g *GitService) ResetStaged() error {
 	cmd := exec.Command("git", "reset")
@@ -508,6 +613,67 @@ func (g *GitService) StageFiles(files []string) error {
 	return nil
 }
 
+// diffBase is the commit GetDiffWithUntracked diffs against: HEAD, or the
+// empty tree before the first commit.
+func (g *GitService) diffBase() (string, error) {
+	if exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run() == nil {
+		return "HEAD", nil
+	}
+	output, err := exec.Command("git", "hash-object", "-t", "tree", os.DevNull).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the empty tree: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StageSelection stages a selection of whole files and hunk IDs ("path#N").
+// Whole files go through StageFiles; partial files are staged by applying a
+// patch of only the selected hunks to the index with `git apply --cached`.
+// The hunks are taken from diff, the GetDiffWithUntracked output the IDs were
+// numbered from (see PreCommitData.HunkDiff), so they are staged by content.
+// The index must be at HEAD (see ResetStaged). IDs that do not resolve in
+// diff, including all of them when diff is "", are taken as literal paths.
+func (g *GitService) StageSelection(selection []string, diff string) error {
+	hunkFiles := make(map[string]DiffFile)
+	for _, file := range ParseDiffHunks(diff) {
+		hunkFiles[file.Path] = file
+	}
+
+	var files []string
+	hunks := make(map[string]map[int]bool)
+	var partialOrder []string
+	for _, item := range selection {
+		path, n, isHunk := SplitHunkID(item)
+		if isHunk && n > len(hunkFiles[path].Hunks) {
+			isHunk = false
+		}
+		if !isHunk {
+			files = append(files, item)
+			continue
+		}
+		if hunks[path] == nil {
+			hunks[path] = make(map[int]bool)
+			partialOrder = append(partialOrder, path)
+		}
+		hunks[path][n] = true
+	}
+
+	if err := g.StageFiles(files); err != nil {
+		return err
+	}
+
+	for _, path := range partialOrder {
+		cmd := exec.Command("git", "apply", "--cached", "--recount", "-")
+		cmd.Stdin = strings.NewReader(buildHunkPatch(hunkFiles[path], hunks[path]))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to stage hunks of %s: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
 // ConfirmAction performs the actual commit and optional push
 func (g *GitService) ConfirmAction(message string, quiet *bool, push *bool, dryRun *bool, noVerify *bool) error {
 	if *dryRun {
@@ -542,15 +708,17 @@ func (g *GitService) ConfirmAction(message string, quiet *bool, push *bool, dryR
 	return nil
 }
 
-func (g *GitService) GetDiff() (*PreCommitData, error) {
+// GetDiff diffs the working tree against base on the default remote, or
+// against the remote's HEAD branch when base is "".
+func (g *GitService) GetDiff(base string) (*PreCommitData, error) {
 	// Get all remotes
 	remotesOutput, err := exec.Command("git", "remote").Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get remotes: %v", err)
+		return nil, Errorf(ErrCodeRemoteFailed, "failed to get remotes: %v", err)
 	}
 	remotes := strings.Fields(string(remotesOutput))
 	if len(remotes) == 0 {
-		return nil, fmt.Errorf("no git remotes configured")
+		return nil, Errorf(ErrCodeRemoteFailed, "no git remotes configured")
 	}
 
 	// Prefer 'origin' if it exists
@@ -564,27 +732,30 @@ func (g *GitService) GetDiff() (*PreCommitData, error) {
 
 	// Fetch the remote to ensure it's up-to-date
 	if err := exec.Command("git", "fetch", remoteName).Run(); err != nil {
-		return nil, fmt.Errorf("failed to fetch remote '%s': %v", remoteName, err)
-	}
-
-	// Get remote details to find the HEAD branch
-	defaultBranchOutput, err := exec.Command(
-		"git",
-		"remote",
-		"show",
-		remoteName,
-	).Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get details for remote '%s': %v", remoteName, err)
-	}
+		return nil, Errorf(ErrCodeRemoteFailed, "failed to fetch remote '%s': %v", remoteName, err)
+	}
+
+	headBranchName := base
+	if headBranchName == "" {
+		// Get remote details to find the HEAD branch
+		defaultBranchOutput, err := exec.Command(
+			"git",
+			"remote",
+			"show",
+			remoteName,
+		).Output()
+		if err != nil {
+			return nil, Errorf(ErrCodeRemoteFailed, "failed to get details for remote '%s': %v", remoteName, err)
+		}
 
-	// Extract the HEAD branch name (e.g., 'main' or 'master')
-	headBranchMatch := regexp.MustCompile(`HEAD branch: (.*)`).
-		FindStringSubmatch(string(defaultBranchOutput))
-	if len(headBranchMatch) < 2 {
-		return nil, fmt.Errorf("could not determine HEAD branch for remote '%s'", remoteName)
+		// Extract the HEAD branch name (e.g., 'main' or 'master')
+		headBranchMatch := regexp.MustCompile(`HEAD branch: (.*)`).
+			FindStringSubmatch(string(defaultBranchOutput))
+		if len(headBranchMatch) < 2 {
+			return nil, Errorf(ErrCodeRemoteFailed, "could not determine HEAD branch for remote '%s'", remoteName)
+		}
+		headBranchName = headBranchMatch[1]
 	}
-	headBranchName := headBranchMatch[1]
 
 	// Diff against the remote's HEAD branch
 	diff, err := exec.Command(
@@ -593,7 +764,7 @@ func (g *GitService) GetDiff() (*PreCommitData, error) {
 		fmt.Sprintf("%s/%s", remoteName, headBranchName),
 	).Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get diff against '%s/%s': %v", remoteName, headBranchName, err)
+		return nil, Errorf(ErrCodeRemoteFailed, "failed to get diff against '%s/%s': %v", remoteName, headBranchName, err)
 	}
 
 	return &PreCommitData{
@@ -604,60 +775,163 @@ func (g *GitService) GetDiff() (*PreCommitData, error) {
 	}, nil
 }
 
+// pullRequestTarget is where the current branch's pull request lives.
+type pullRequestTarget struct {
+	remoteName string
+	remote     RemoteInfo
+	forge      Forge
+}
+
+// resolvePullRequestTarget finds the remote to push to and its forge.
+func (g *GitService) resolvePullRequestTarget() (*pullRequestTarget, error) {
+	remoteName, err := g.GetRemoteName()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := g.GetRemoteInfo(remoteName)
+	if err != nil {
+		return nil, err
+	}
+	forge, err := DetectForge(remote)
+	if err != nil {
+		return nil, err
+	}
+	return &pullRequestTarget{remoteName: remoteName, remote: remote, forge: forge}, nil
+}
+
+// FindOpenPullRequest returns the open pull request for the current branch,
+// or nil when there is none, together with what the forge calls it.
+func (g *GitService) FindOpenPullRequest(ctx context.Context) (*PullRequest, string, error) {
+	target, err := g.resolvePullRequestTarget()
+	if err != nil {
+		return nil, "", err
+	}
+	branchName, err := g.GetCurrentBranchName()
+	if err != nil {
+		return nil, "", err
+	}
+	pr, err := target.forge.FindPullRequest(ctx, branchName)
+	return pr, target.forge.Noun(), err
+}
+
+// CreatePullRequest pushes the current branch and opens a pull request on
+// the forge its remote is hosted on. It returns the URL the forge reports;
+// dry runs return "".
 func (g *GitService) CreatePullRequest(
+	ctx context.Context,
 	message string,
 	quiet *bool,
 	dryRun *bool,
 	draft *bool,
-) error {
+	base *string,
+	labels *[]string,
+	assignees *[]string,
+) (string, error) {
 	title, body, _ := strings.Cut(message, "\n")
 
+	target, err := g.resolvePullRequestTarget()
+	if err != nil {
+		return "", err
+	}
+	forge := target.forge
+
 	if *dryRun {
 		if !*quiet {
 			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
 			color.New(color.FgCyan).
-				Printf("Would create a pull request with title: %s\n", title)
+				Printf("Would create a %s on %s with title: %s\n", forge.Noun(), target.remote.Host, title)
 		}
-		return nil
+		return "", nil
 	}
 
 	// Get the current branch name
 	branchName, err := g.GetCurrentBranchName()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Get the remote name
-	remoteName, err := g.GetRemoteName()
+	// Push the current branch to the remote
+	if err := g.PushBranch(target.remoteName, branchName, quiet); err != nil {
+		return "", err
+	}
+
+	url, err := forge.CreatePullRequest(ctx, PullRequestOptions{
+		Title:     title,
+		Body:      WrapPullRequestBody(body),
+		Head:      branchName,
+		Base:      *base,
+		Draft:     *draft,
+		Labels:    *labels,
+		Assignees: *assignees,
+		Quiet:     *quiet,
+	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Push the current branch to the remote
-	if err := g.PushBranch(remoteName, branchName, quiet); err != nil {
-		return err
+	if !*quiet {
+		color.New(color.FgGreen).Printf("✔ Successfully created a %s!\n", forge.Noun())
+	}
+
+	return url, nil
+}
+
+// UpdatePullRequest pushes the current branch and replaces the title and the
+// generated part of pr's body with message, keeping text written around the
+// opencommit markers.
+func (g *GitService) UpdatePullRequest(
+	ctx context.Context,
+	pr *PullRequest,
+	message string,
+	quiet *bool,
+	dryRun *bool,
+) (string, error) {
+	title, body, _ := strings.Cut(message, "\n")
+
+	target, err := g.resolvePullRequestTarget()
+	if err != nil {
+		return "", err
 	}
+	forge := target.forge
 
-	args := []string{"pr", "create", "--title", title, "--body", body}
-	if *draft {
-		args = append(args, "--draft")
+	if *dryRun {
+		if !*quiet {
+			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
+			color.New(color.FgCyan).
+				Printf("Would update %s %s with title: %s\n", forge.Noun(), pr.URL, title)
+		}
+		return pr.URL, nil
 	}
 
-	cmd := exec.Command("gh", args...)
-	if !*quiet {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	branchName, err := g.GetCurrentBranchName()
+	if err != nil {
+		return "", err
+	}
+	if err := g.PushBranch(target.remoteName, branchName, quiet); err != nil {
+		return "", err
 	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create pull request: %v", err)
+	url, err := forge.UpdatePullRequest(ctx, pr, title, MergePullRequestBody(pr.Body, body), *quiet)
+	if err != nil {
+		return "", err
 	}
 
 	if !*quiet {
-		color.New(color.FgGreen).Println("✔ Successfully created a pull request!")
+		color.New(color.FgGreen).Printf("✔ Successfully updated the %s!\n", forge.Noun())
 	}
 
-	return nil
+	return url, nil
+}
+
+// pullRequestURL returns the last URL printed by gh pr create or glab mr create.
+func pullRequestURL(output string) string {
+	lines := strings.Fields(output)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], "https://") || strings.HasPrefix(lines[i], "http://") {
+			return lines[i]
+		}
+	}
+	return ""
 }
 
 func (g *GitService) GetCurrentBranchName() (string, error) {
@@ -670,13 +944,16 @@ func (g *GitService) GetCurrentBranchName() (string, error) {
 }
 
 func (g *GitService) GetRemoteName() (string, error) {
+	if g.detached {
+		return "", Errorf(ErrCodeRemoteFailed, "repository lookups are disabled")
+	}
 	remotesOutput, err := exec.Command("git", "remote").Output()
 	if err != nil {
-		return "", fmt.Errorf("failed to get remotes: %v", err)
+		return "", Errorf(ErrCodeRemoteFailed, "failed to get remotes: %v", err)
 	}
 	remotes := strings.Fields(string(remotesOutput))
 	if len(remotes) == 0 {
-		return "", fmt.Errorf("no git remotes configured")
+		return "", Errorf(ErrCodeRemoteFailed, "no git remotes configured")
 	}
 
 	// Prefer 'origin' if it exists
@@ -697,7 +974,7 @@ func (g *GitService) PushBranch(remoteName, branchName string, quiet *bool) erro
 		cmd.Stderr = os.Stderr
 	}
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to push branch '%s' to remote '%s': %v", branchName, remoteName, err)
+		return Errorf(ErrCodePushFailed, "failed to push branch '%s' to remote '%s': %v", branchName, remoteName, err)
 	}
 	return nil
 }
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CommitInfo is a commit read from history.
+type CommitInfo struct {
+	Hash        string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	AuthorDate  string
+	Message     string
+}
+
+// Subject returns the first line of the commit message.
+func (c CommitInfo) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// ListCommits returns the commits in revRange (anything `git log` accepts),
+// oldest first.
+func (g *GitService) ListCommits(revRange string) ([]CommitInfo, error) {
+	cmd := exec.Command("git", "log",
+		"--reverse", "--topo-order",
+		"--format=%H%x00%P%x00%an%x00%ae%x00%aI%x00%B%x1e",
+		revRange, "--")
+
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, Errorf(ErrCodeInvalidArgument, "git log %s: %v: %s", revRange, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var commits []CommitInfo
+	for _, record := range strings.Split(out.String(), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 6)
+		if len(fields) < 6 {
+			continue
+		}
+		commits = append(commits, CommitInfo{
+			Hash:        fields[0],
+			Parents:     strings.Fields(fields[1]),
+			AuthorName:  fields[2],
+			AuthorEmail: fields[3],
+			AuthorDate:  fields[4],
+			Message:     strings.TrimSpace(fields[5]),
+		})
+	}
+	return commits, nil
+}
+
+// GetCommitDiff returns the patch a commit introduces relative to its first
+// parent (or the empty tree for a root commit).
+func (g *GitService) GetCommitDiff(hash string) (string, error) {
+	output, err := exec.Command("git", "show", "--format=", "--no-color", "--first-parent", hash).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff of %s: %v", ShortHash(hash), err)
+	}
+	return string(output), nil
+}
+
+// GetUpstream returns the upstream of the current branch (e.g. origin/main),
+// or "" when none is configured.
+func (g *GitService) GetUpstream() string {
+	output, err := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// OnRemoteBranch reports whether commit is reachable from any
+// remote-tracking branch.
+func (g *GitService) OnRemoteBranch(commit string) bool {
+	output, err := exec.Command("git", "branch", "-r", "--contains", commit).Output()
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
+
+// IsAncestor reports whether commit is reachable from rev.
+func (g *GitService) IsAncestor(commit, rev string) bool {
+	return exec.Command("git", "merge-base", "--is-ancestor", commit, rev).Run() == nil
+}
+
+// RewriteCommitMessages recreates commits (oldest first, a contiguous line of
+// history ending at HEAD) with the given messages, keeping trees, authors
+// and dates, and moves the current branch to the result. Commits before the
+// first changed message are kept as they are. It returns the new HEAD.
+func (g *GitService) RewriteCommitMessages(commits []CommitInfo, messages []string) (string, error) {
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits to rewrite")
+	}
+	oldHead := commits[len(commits)-1].Hash
+
+	rewritten := make(map[string]string, len(commits))
+	for i, commit := range commits {
+		parents := make([]string, len(commit.Parents))
+		changed := messages[i] != commit.Message
+		for j, parent := range commit.Parents {
+			parents[j] = parent
+			if newParent, ok := rewritten[parent]; ok && newParent != parent {
+				parents[j] = newParent
+				changed = true
+			}
+		}
+		if !changed {
+			rewritten[commit.Hash] = commit.Hash
+			continue
+		}
+
+		args := []string{"commit-tree", commit.Hash + "^{tree}"}
+		for _, parent := range parents {
+			args = append(args, "-p", parent)
+		}
+		args = append(args, "-F", "-")
+
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+commit.AuthorName,
+			"GIT_AUTHOR_EMAIL="+commit.AuthorEmail,
+			"GIT_AUTHOR_DATE="+commit.AuthorDate,
+		)
+		cmd.Stdin = strings.NewReader(strings.TrimSpace(messages[i]) + "\n")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return "", Errorf(ErrCodeCommitFailed, "failed to rewrite %s: %v: %s", ShortHash(commit.Hash), err, strings.TrimSpace(stderr.String()))
+		}
+		rewritten[commit.Hash] = strings.TrimSpace(string(output))
+	}
+
+	newHead := rewritten[oldHead]
+	if newHead == oldHead {
+		return oldHead, nil
+	}
+	if output, err := exec.Command("git", "update-ref", "-m", "opencommit: reword", "HEAD", newHead, oldHead).CombinedOutput(); err != nil {
+		return "", Errorf(ErrCodeCommitFailed, "failed to move HEAD: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return newHead, nil
+}
+
+// ShortHash abbreviates a commit hash for display.
+func ShortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// DefaultBaseBranch returns the branch feature work is merged into: the
+// remote's HEAD branch (e.g. origin/main) when known, otherwise a local
+// main or master.
+func (g *GitService) DefaultBaseBranch() (string, error) {
+	if output, err := exec.Command("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output(); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := g.ResolveRevision(branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", Errorf(ErrCodeInvalidArgument, "could not determine the base branch; pass --base")
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func (g *GitService) MergeBase(a, b string) (string, error) {
+	output, err := exec.Command("git", "merge-base", a, b).Output()
+	if err != nil {
+		return "", Errorf(ErrCodeInvalidArgument, "no common ancestor of %s and %s", a, b)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetDiffBetween returns the diff from one revision to another.
+func (g *GitService) GetDiffBetween(from, to string) (string, error) {
+	output, err := exec.Command("git", "diff", "--no-color", from, to, "--").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff %s..%s: %v", ShortHash(from), ShortHash(to), err)
+	}
+	return string(output), nil
+}
+
+// HasStagedChanges reports whether the index differs from HEAD.
+func (g *GitService) HasStagedChanges() bool {
+	return exec.Command("git", "diff", "--cached", "--quiet").Run() != nil
+}
+
+// SoftReset moves the current branch to rev, keeping the index and working
+// tree.
+func (g *GitService) SoftReset(rev string) error {
+	if output, err := exec.Command("git", "reset", "--soft", rev).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reset to %s: %v: %s", ShortHash(rev), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	prepareCommitMsgHook = "prepare-commit-msg"
+	// hookBackupSuffix is appended to a pre-existing hook that opencommit
+	// chains to instead of overwriting.
+	hookBackupSuffix = ".opencommit-backup"
+	// hookMarker identifies hook scripts written by opencommit.
+	hookMarker = "# opencommit prepare-commit-msg hook"
+)
+
+// HookStatus describes the prepare-commit-msg hook of the current repository.
+type HookStatus struct {
+	HooksDir  string
+	HookPath  string
+	Installed bool // the hook is the one written by opencommit
+	Foreign   bool // a hook exists that opencommit did not write
+	Chained   bool // a previous hook was kept and is called first
+}
+
+// HookService installs and inspects the prepare-commit-msg hook
+type HookService struct{}
+
+var (
+	hookServiceInstance *HookService
+	hookServiceOnce     sync.Once
+)
+
+func NewHookService() *HookService {
+	hookServiceOnce.Do(func() {
+		hookServiceInstance = &HookService{}
+	})
+
+	return hookServiceInstance
+}
+
+// HooksDir resolves the hooks directory, honouring core.hooksPath.
+func (h *HookService) HooksDir() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve hooks directory: %v", err)
+	}
+	dir, err := filepath.Abs(strings.TrimSpace(string(output)))
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Status reports whether the hook is installed and whether a previous hook
+// is being chained.
+func (h *HookService) Status() (*HookStatus, error) {
+	dir, err := h.HooksDir()
+	if err != nil {
+		return nil, err
+	}
+	status := &HookStatus{
+		HooksDir: dir,
+		HookPath: filepath.Join(dir, prepareCommitMsgHook),
+	}
+
+	content, err := os.ReadFile(status.HookPath)
+	switch {
+	case err == nil:
+		status.Installed = strings.Contains(string(content), hookMarker)
+		status.Foreign = !status.Installed
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read %s: %v", status.HookPath, err)
+	}
+
+	if _, err := os.Stat(status.HookPath + hookBackupSuffix); err == nil {
+		status.Chained = true
+	}
+	return status, nil
+}
+
+// Install writes the hook script. An existing foreign hook is renamed to
+// <hook>.opencommit-backup and called before opencommit runs. Re-installing
+// refreshes the script in place.
+func (h *HookService) Install() (*HookStatus, error) {
+	status, err := h.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(status.HooksDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %v", err)
+	}
+
+	if status.Foreign {
+		backup := status.HookPath + hookBackupSuffix
+		if status.Chained {
+			return nil, fmt.Errorf(
+				"both %s and %s exist; remove one of them before installing",
+				status.HookPath, backup,
+			)
+		}
+		if err := os.Rename(status.HookPath, backup); err != nil {
+			return nil, fmt.Errorf("failed to back up existing hook: %v", err)
+		}
+		status.Chained = true
+		status.Foreign = false
+	}
+
+	if err := os.WriteFile(status.HookPath, []byte(hookScript()), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to write hook: %v", err)
+	}
+	status.Installed = true
+	return status, nil
+}
+
+// Uninstall removes the opencommit hook and restores a chained hook, if any.
+func (h *HookService) Uninstall() (*HookStatus, error) {
+	status, err := h.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status.Foreign {
+		return nil, fmt.Errorf("%s was not installed by opencommit; leaving it untouched", status.HookPath)
+	}
+	if !status.Installed {
+		return nil, fmt.Errorf("opencommit hook is not installed")
+	}
+
+	if err := os.Remove(status.HookPath); err != nil {
+		return nil, fmt.Errorf("failed to remove hook: %v", err)
+	}
+	status.Installed = false
+
+	if status.Chained {
+		if err := os.Rename(status.HookPath+hookBackupSuffix, status.HookPath); err != nil {
+			return nil, fmt.Errorf("failed to restore previous hook: %v", err)
+		}
+		status.Chained = false
+		status.Foreign = true
+	}
+	return status, nil
+}
+
+// hookScript renders the shell script. It prefers the binary that installed
+// it and falls back to opencommit on PATH; failures never block the commit.
+func hookScript() string {
+	executable := "opencommit"
+	if exe, err := os.Executable(); err == nil {
+		executable = exe
+	}
+
+	return fmt.Sprintf(`#!/bin/sh
+%s
+# Installed by "opencommit hook install"; remove with "opencommit hook uninstall".
+
+backup="$(dirname "$0")/%s%s"
+if [ -x "$backup" ]; then
+	"$backup" "$@" || exit $?
+fi
+
+OPENCOMMIT=%s
+[ -x "$OPENCOMMIT" ] || OPENCOMMIT=opencommit
+"$OPENCOMMIT" hook run "$@" < /dev/null || true
+`, hookMarker, prepareCommitMsgHook, hookBackupSuffix, shellQuote(executable))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShouldSkipHookSource reports whether the prepare-commit-msg source argument
+// means the user or git already supplied a message: -m/-F ("message"),
+// merges ("merge"), squashes ("squash") and amend/-c/-C ("commit").
+func ShouldSkipHookSource(source string) bool {
+	switch source {
+	case "message", "merge", "squash", "commit":
+		return true
+	default:
+		return false
+	}
+}
+
+// HasCommitMessageContent reports whether a commit message file already
+// contains non-comment text, e.g. from a commit template. Everything below
+// the `git commit -v` scissors line is ignored.
+func HasCommitMessageContent(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") && strings.Contains(trimmed, ">8") {
+			break
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return true
+		}
+	}
+	return false
+}
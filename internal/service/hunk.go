@@ -0,0 +1,169 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiffHunk is one `@@` hunk of a file diff. ID has the form "path#N" with N
+// counting from 1 in diff order.
+type DiffHunk struct {
+	ID     string
+	Header string
+	Body   string
+}
+
+// DiffFile is one `diff --git` section split into its header (everything up
+// to the first hunk) and hunks.
+type DiffFile struct {
+	Path   string
+	Header string
+	Hunks  []DiffHunk
+}
+
+// ParseDiffHunks splits a unified diff into per-file headers and hunks.
+func ParseDiffHunks(diff string) []DiffFile {
+	_, sections := SplitDiffSections(diff)
+
+	var files []DiffFile
+	for _, section := range sections {
+		path := DiffSectionPath(section)
+		file := DiffFile{Path: path}
+
+		lines := strings.SplitAfter(section, "\n")
+		var header strings.Builder
+		var current *DiffHunk
+		var body strings.Builder
+		flush := func() {
+			if current != nil {
+				current.Body = body.String()
+				file.Hunks = append(file.Hunks, *current)
+				body.Reset()
+			}
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "@@") {
+				flush()
+				current = &DiffHunk{
+					ID:     fmt.Sprintf("%s#%d", path, len(file.Hunks)+1),
+					Header: strings.TrimRight(line, "\n"),
+				}
+				body.WriteString(line)
+				continue
+			}
+			if current == nil {
+				header.WriteString(line)
+			} else {
+				body.WriteString(line)
+			}
+		}
+		flush()
+		file.Header = header.String()
+		files = append(files, file)
+	}
+	return files
+}
+
+// SplitHunkID splits "path#N" into its path and hunk number. ok is false for
+// plain file paths.
+func SplitHunkID(id string) (string, int, bool) {
+	idx := strings.LastIndex(id, "#")
+	if idx <= 0 {
+		return id, 0, false
+	}
+	n, err := strconv.Atoi(id[idx+1:])
+	if err != nil || n <= 0 {
+		return id, 0, false
+	}
+	return id[:idx], n, true
+}
+
+// AnnotateDiffHunks inserts a `[hunk path#N]` marker line before every hunk
+// of files that have more than one hunk, so the model can refer to them.
+func AnnotateDiffHunks(diff string) string {
+	prefix, sections := SplitDiffSections(diff)
+	if sections == nil {
+		return diff
+	}
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	for _, file := range ParseDiffHunks(diff) {
+		out.WriteString(file.Header)
+		for _, hunk := range file.Hunks {
+			if len(file.Hunks) > 1 {
+				fmt.Fprintf(&out, "[hunk %s]\n", hunk.ID)
+			}
+			out.WriteString(hunk.Body)
+		}
+	}
+	return out.String()
+}
+
+// CollapseHunkSelection normalizes a selection of file paths and hunk IDs:
+// a file whose hunks are all selected becomes its plain path, hunk IDs of a
+// file that is also selected whole are dropped, and order is preserved.
+// diff must be the complete, untruncated diff the IDs were numbered from, so
+// a file is only widened to its path when every one of its hunks was picked.
+func CollapseHunkSelection(selection []string, diff string) []string {
+	hunkCount := make(map[string]int)
+	for _, file := range ParseDiffHunks(diff) {
+		hunkCount[file.Path] = len(file.Hunks)
+	}
+
+	whole := make(map[string]bool)
+	picked := make(map[string]map[int]bool)
+	var order []string
+	seen := make(map[string]bool)
+	for _, item := range selection {
+		path, n, isHunk := SplitHunkID(item)
+		if isHunk && hunkCount[path] < n {
+			// Unknown hunk number; fall back to a path containing '#'.
+			path, isHunk = item, false
+		}
+		if !seen[path] {
+			seen[path] = true
+			order = append(order, path)
+		}
+		if !isHunk {
+			whole[path] = true
+			continue
+		}
+		if picked[path] == nil {
+			picked[path] = make(map[int]bool)
+		}
+		picked[path][n] = true
+	}
+
+	var result []string
+	for _, path := range order {
+		hunks := picked[path]
+		if whole[path] || len(hunks) == hunkCount[path] {
+			result = append(result, path)
+			continue
+		}
+		for n := 1; n <= hunkCount[path]; n++ {
+			if hunks[n] {
+				result = append(result, fmt.Sprintf("%s#%d", path, n))
+			}
+		}
+	}
+	return result
+}
+
+// buildHunkPatch returns a patch for file containing only the given hunks.
+func buildHunkPatch(file DiffFile, hunks map[int]bool) string {
+	var patch strings.Builder
+	patch.WriteString(file.Header)
+	for i, hunk := range file.Hunks {
+		if hunks[i+1] {
+			patch.WriteString(hunk.Body)
+		}
+	}
+	out := patch.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out
+}
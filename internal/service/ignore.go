@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// IgnoreFileName is the repository file listing paths whose changes are
+// left out of prompts (but still committed).
+const IgnoreFileName = ".opencommitignore"
+
+// DefaultIgnorePatterns cover lockfiles, generated code, vendored
+// dependencies and snapshots. A `!pattern` in ignore.patterns or
+// .opencommitignore re-includes a path.
+var DefaultIgnorePatterns = []string{
+	"go.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"poetry.lock",
+	"Pipfile.lock",
+	"composer.lock",
+	"Gemfile.lock",
+	"*.pb.go",
+	"*_pb2.py",
+	"*.min.js",
+	"*.min.css",
+	"*.snap",
+	"__snapshots__/",
+	"vendor/",
+	"node_modules/",
+}
+
+// lockfileManifests maps lockfiles to the manifest they are committed with.
+var lockfileManifests = map[string]string{
+	"go.sum":            "go.mod",
+	"package-lock.json": "package.json",
+	"yarn.lock":         "package.json",
+	"pnpm-lock.yaml":    "package.json",
+	"Cargo.lock":        "Cargo.toml",
+	"poetry.lock":       "pyproject.toml",
+	"Pipfile.lock":      "Pipfile",
+	"composer.lock":     "composer.json",
+	"Gemfile.lock":      "Gemfile",
+}
+
+type ignoreRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// IgnoreMatcher matches paths against gitignore-style patterns; the last
+// matching pattern wins.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher compiles gitignore-style patterns. Blank lines and
+// comments are skipped, as are patterns that cannot be compiled.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		negate := false
+		if strings.HasPrefix(p, "!") {
+			negate, p = true, p[1:]
+		}
+		if re, err := compileIgnorePattern(p); err == nil {
+			m.rules = append(m.rules, ignoreRule{re: re, negate: negate})
+		}
+	}
+	return m
+}
+
+// Match reports whether file (slash-separated, relative to the repository
+// root) is ignored.
+func (m *IgnoreMatcher) Match(file string) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.re.MatchString(file) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// compileIgnorePattern turns one gitignore pattern into a regexp over
+// repository-relative paths.
+func compileIgnorePattern(p string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var body strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			body.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			body.WriteString(".*")
+			i++
+		case c == '*':
+			body.WriteString("[^/]*")
+		case c == '?':
+			body.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in %q", p)
+			}
+			class := p[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			body.WriteString("[" + class + "]")
+			i += end
+		default:
+			body.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	suffix := "(?:/.*)?$"
+	if dirOnly {
+		suffix = "/.*$"
+	}
+	return regexp.Compile(prefix + body.String() + suffix)
+}
+
+// LoadIgnoreMatcher combines DefaultIgnorePatterns, ignore.patterns and the
+// repository's .opencommitignore, in that order.
+func LoadIgnoreMatcher() *IgnoreMatcher {
+	patterns := append([]string(nil), DefaultIgnorePatterns...)
+	patterns = append(patterns, viper.GetStringSlice("ignore.patterns")...)
+	if root, err := NewGitService().GetRepoRoot(); err == nil {
+		if f, err := os.Open(filepath.Join(root, IgnoreFileName)); err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				patterns = append(patterns, scanner.Text())
+			}
+			f.Close()
+		}
+	}
+	return NewIgnoreMatcher(patterns)
+}
+
+// OmitIgnoredSections replaces the section of every ignored file in diff
+// with its `diff --git` header and a one-line stat summary, so the file is
+// still listed but its content is not sent. It returns the new diff and the
+// ignored files.
+func OmitIgnoredSections(diff string, m *IgnoreMatcher) (string, []string) {
+	prefix, sections := SplitDiffSections(diff)
+	if sections == nil {
+		return diff, nil
+	}
+
+	var ignored []string
+	var out strings.Builder
+	out.WriteString(prefix)
+	for _, section := range sections {
+		file := DiffSectionPath(section)
+		if file == "" || !m.Match(file) {
+			out.WriteString(section)
+			continue
+		}
+		ignored = append(ignored, file)
+		header, _, _ := strings.Cut(section, "\n")
+		added, removed := countChangedLines(section)
+		fmt.Fprintf(&out, "%s\n(changes omitted from prompt: +%d -%d lines)\n", header, added, removed)
+	}
+	return out.String(), ignored
+}
+
+// OmitIgnoredFiles applies the configured ignore patterns to diff.
+func OmitIgnoredFiles(diff string) (string, []string) {
+	return OmitIgnoredSections(diff, LoadIgnoreMatcher())
+}
+
+// countChangedLines counts added and removed lines in a diff section.
+func countChangedLines(section string) (int, int) {
+	added, removed := 0, 0
+	inHeader := true
+	for _, line := range strings.Split(section, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+		case inHeader:
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// AttachIgnoredFiles adds the ignored files to selection. The model never
+// sees their content, so it cannot pick them, but they are part of the
+// change being committed. Selection entries may be hunk IDs.
+func AttachIgnoredFiles(selection []string, ignored []string) []string {
+	selected := make(map[string]bool, len(selection))
+	for _, item := range selection {
+		file, _, _ := SplitHunkID(item)
+		selected[file] = true
+	}
+
+	result := append([]string(nil), selection...)
+	for _, file := range ignored {
+		if !selected[file] {
+			selected[file] = true
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// AttachIgnoredToPlan moves each unplanned ignored file into the planned
+// commit it belongs with most closely (see ignoredFileAffinity), or into the
+// last commit when none is related. Unplanned files that are not ignored are
+// returned.
+func AttachIgnoredToPlan(plan []CommitPlanEntry, unplanned []string, ignored []string) ([]CommitPlanEntry, []string) {
+	isIgnored := make(map[string]bool, len(ignored))
+	for _, f := range ignored {
+		isIgnored[f] = true
+	}
+
+	var remaining []string
+	for _, file := range unplanned {
+		if !isIgnored[file] || len(plan) == 0 {
+			remaining = append(remaining, file)
+			continue
+		}
+		target, best := len(plan)-1, 0
+		for i := range plan {
+			if score := ignoredFileAffinity(file, plan[i].Files); score > best {
+				target, best = i, score
+			}
+		}
+		plan[target].Files = append(plan[target].Files, file)
+	}
+	return plan, remaining
+}
+
+// ignoredFileAffinity scores how closely an ignored file belongs with files;
+// 0 means not at all. A lockfile with its manifest, generated code with the
+// source of the same stem (api/foo.pb.go with api/foo.proto) and vendored
+// code with the manifest that pulled it in rank first, then a file in the
+// same directory, then the deepest shared parent directory.
+func ignoredFileAffinity(file string, files []string) int {
+	const paired, sameDir = 1000, 100
+
+	dir, base := path.Dir(file), path.Base(file)
+	vendored := strings.HasPrefix(file, "vendor/") || strings.Contains(file, "/vendor/") ||
+		strings.HasPrefix(file, "node_modules/") || strings.Contains(file, "/node_modules/")
+	best := 0
+	for _, other := range files {
+		otherDir, otherBase := path.Dir(other), path.Base(other)
+		_, otherIsLockfile := lockfileManifests[otherBase]
+		switch {
+		case other == file:
+			continue
+		case lockfileManifests[base] == otherBase && otherDir == dir:
+			return paired
+		case otherDir == dir && otherBase != base && fileStem(otherBase) == fileStem(base):
+			return paired
+		case vendored && (isManifest(otherBase) || otherIsLockfile) &&
+			(otherDir == "." || strings.HasPrefix(file, otherDir+"/")):
+			return paired
+		case otherDir == dir:
+			best = max(best, sameDir)
+		default:
+			best = max(best, sharedDirDepth(dir, otherDir))
+		}
+	}
+	return best
+}
+
+// fileStem is a file name up to its first dot, without the _pb2 suffix of
+// generated Python, so foo.proto, foo.pb.go and foo_pb2.py share "foo".
+func fileStem(base string) string {
+	stem, _, _ := strings.Cut(base, ".")
+	return strings.TrimSuffix(stem, "_pb2")
+}
+
+// sharedDirDepth counts the leading directories a and b have in common.
+func sharedDirDepth(a, b string) int {
+	if a == "." || b == "." {
+		return 0
+	}
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	depth := 0
+	for depth < len(as) && depth < len(bs) && as[depth] == bs[depth] {
+		depth++
+	}
+	return depth
+}
+
+func isManifest(base string) bool {
+	for _, manifest := range lockfileManifests {
+		if manifest == base {
+			return true
+		}
+	}
+	return false
+}
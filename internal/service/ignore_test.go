@@ -0,0 +1,54 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttachIgnoredFiles(t *testing.T) {
+	got := AttachIgnoredFiles([]string{"main.go#1", "api/foo.proto"}, []string{"go.sum", "api/foo.pb.go", "api/foo.proto"})
+	want := []string{"main.go#1", "api/foo.proto", "go.sum", "api/foo.pb.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AttachIgnoredFiles = %v, want %v", got, want)
+	}
+}
+
+func TestAttachIgnoredToPlan(t *testing.T) {
+	plan := []CommitPlanEntry{
+		{Files: []string{"api/bar.go"}, Message: "feat(api): add bar"},
+		{Files: []string{"api/foo.proto"}, Message: "feat(api): add foo"},
+		{Files: []string{"web/package.json"}, Message: "build: bump deps"},
+		{Files: []string{"docs/guide.md"}, Message: "docs: update guide"},
+	}
+	ignored := []string{"api/foo.pb.go", "go.sum", "web/package-lock.json", "web/src/__snapshots__/app.snap", "vendor/x/y.go"}
+	unplanned := append([]string{"notes.txt"}, ignored...)
+
+	got, remaining := AttachIgnoredToPlan(plan, unplanned, ignored)
+	want := [][]string{
+		{"api/bar.go"},
+		{"api/foo.proto", "api/foo.pb.go"},
+		{"web/package.json", "web/package-lock.json", "web/src/__snapshots__/app.snap"},
+		// Nothing else is related to a lone go.sum or to vendor/, so they
+		// go into the last commit.
+		{"docs/guide.md", "go.sum", "vendor/x/y.go"},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i].Files, want[i]) {
+			t.Errorf("commit %d files = %v, want %v", i+1, got[i].Files, want[i])
+		}
+	}
+	if !reflect.DeepEqual(remaining, []string{"notes.txt"}) {
+		t.Errorf("remaining = %v, want only the file that is not ignored", remaining)
+	}
+}
+
+func TestAttachIgnoredToPlanPairsLockfileWithManifest(t *testing.T) {
+	plan := []CommitPlanEntry{
+		{Files: []string{"go.mod", "main.go"}, Message: "build: add dependency"},
+		{Files: []string{"README.md"}, Message: "docs: mention dependency"},
+	}
+	got, _ := AttachIgnoredToPlan(plan, []string{"go.sum"}, []string{"go.sum"})
+	if !reflect.DeepEqual(got[0].Files, []string{"go.mod", "main.go", "go.sum"}) {
+		t.Errorf("go.sum went to %v, want the commit with go.mod", got)
+	}
+}
@@ -123,8 +123,10 @@ func (h *InteractionService) DisplayDiff(diff string) {
 	fmt.Println()
 }
 
-// ConfirmAutoSelectedFiles prompts the user to confirm, edit, or cancel AI-selected files
-func (h *InteractionService) ConfirmAutoSelectedFiles(files []string) (Action, []string, error) {
+// ConfirmAutoSelectedFiles prompts the user to confirm, edit, or cancel AI-selected files.
+// diff is the change set the selection was made from; it is used to list
+// individual hunks when editing.
+func (h *InteractionService) ConfirmAutoSelectedFiles(files []string, diff string) (Action, []string, error) {
 	var choice string
 	options := []string{"Yes", "Edit", "Cancel"}
 
@@ -154,7 +156,7 @@ func (h *InteractionService) ConfirmAutoSelectedFiles(files []string) (Action, [
 	case "Cancel":
 		return ActionCancel, nil, nil
 	case "Edit":
-		editedFiles, err := h.EditFileList(files)
+		editedFiles, err := h.EditFileList(files, diff)
 		if err != nil {
 			return ActionCancel, nil, err
 		}
@@ -164,13 +166,47 @@ func (h *InteractionService) ConfirmAutoSelectedFiles(files []string) (Action, [
 	}
 }
 
-// EditFileList allows the user to select files from the list
-func (h *InteractionService) EditFileList(files []string) ([]string, error) {
-	// // Create options from the files, with all initially selected
-	// options := make([]huh.Option[string], len(files))
-	// for i, file := range files {
-	// 	options[i] = huh.NewOption(file, file).Selected(true)
-	// }
+// EditFileList allows the user to select files from the list. Files with more
+// than one hunk in diff are listed hunk by hunk so they can be toggled
+// individually; the result is normalized with CollapseHunkSelection. diff is
+// the untruncated diff hunk IDs refer to, or "" to select whole files only.
+func (h *InteractionService) EditFileList(files []string, diff string) ([]string, error) {
+	hunksByPath := make(map[string][]DiffHunk)
+	for _, file := range ParseDiffHunks(diff) {
+		hunksByPath[file.Path] = file.Hunks
+	}
+
+	// Work out which files and hunks are currently selected, keeping order.
+	var paths []string
+	seen := make(map[string]bool)
+	whole := make(map[string]bool)
+	chosen := make(map[string]bool)
+	for _, item := range files {
+		path, _, isHunk := SplitHunkID(item)
+		if _, known := hunksByPath[path]; !isHunk || !known {
+			path = item
+			whole[path] = true
+		} else {
+			chosen[item] = true
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	var options []huh.Option[string]
+	for _, path := range paths {
+		hunks := hunksByPath[path]
+		if len(hunks) <= 1 {
+			options = append(options, huh.NewOption(path, path).Selected(true))
+			continue
+		}
+		for _, hunk := range hunks {
+			label := fmt.Sprintf("%s  %s", hunk.ID, hunk.Header)
+			options = append(options, huh.NewOption(label, hunk.ID).Selected(whole[path] || chosen[hunk.ID]))
+		}
+	}
 
 	// Variable to store selected files
 	var selectedFiles []string
@@ -179,8 +215,8 @@ func (h *InteractionService) EditFileList(files []string) ([]string, error) {
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
-				Title("Select files to include in commit").
-				Options(huh.NewOptions(files...)...).
+				Title("Select files and hunks to include in commit").
+				Options(options...).
 				Value(&selectedFiles).
 				Height(15),
 		),
@@ -197,6 +233,250 @@ func (h *InteractionService) EditFileList(files []string) ([]string, error) {
 		return nil, errors.New("no files selected")
 	}
 
-	return selectedFiles, nil
+	return CollapseHunkSelection(selectedFiles, diff), nil
+}
+
+// DisplayCommitPlan prints a split plan as a numbered list
+func (h *InteractionService) DisplayCommitPlan(plan []CommitPlanEntry, unplanned []string) {
+	underline := color.New(color.Underline)
+	underline.Printf("Proposed %d commits:\n", len(plan))
+	for idx, entry := range plan {
+		subject, _, _ := strings.Cut(entry.Message, "\n")
+		color.New(color.Bold).Printf("\n%d. %s\n", idx+1, subject)
+		for _, f := range entry.Files {
+			fmt.Printf("     - %s\n", f)
+		}
+	}
+	if len(unplanned) > 0 {
+		color.New(color.FgYellow).Printf("\nNot in any commit (left uncommitted):\n")
+		for _, f := range unplanned {
+			fmt.Printf("     - %s\n", f)
+		}
+	}
+	fmt.Println()
+}
+
+// ReviewCommitPlan asks the user to accept, edit, regenerate or cancel a split plan
+func (h *InteractionService) ReviewCommitPlan(
+	plan []CommitPlanEntry,
+	unplanned []string,
+	allFiles []string,
+	opts *CommitOptions,
+) (Action, []CommitPlanEntry, error) {
+	if *opts.NoConfirm {
+		return ActionConfirm, plan, nil
+	}
+
+	h.DisplayCommitPlan(plan, unplanned)
+
+	var selectedAction Action
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[Action]().
+				Title("Create these commits?").
+				Options(
+					huh.NewOption("Yes", ActionConfirm),
+					huh.NewOption("Edit", ActionEdit),
+					huh.NewOption("Regenerate", ActionRegenerate),
+					huh.NewOption("Cancel", ActionCancel),
+				).
+				Value(&selectedAction),
+		),
+	).Run(); err != nil {
+		return "", nil, err
+	}
+
+	if selectedAction == ActionEdit {
+		edited, err := h.EditCommitPlan(plan, allFiles)
+		if err != nil {
+			return "", nil, err
+		}
+		return ActionEdit, edited, nil
+	}
+	return selectedAction, plan, nil
+}
+
+// EditCommitPlan lets the user rewrite each planned message and move files
+// between commits. Commits left without files are dropped.
+func (h *InteractionService) EditCommitPlan(plan []CommitPlanEntry, allFiles []string) ([]CommitPlanEntry, error) {
+	edited := make([]CommitPlanEntry, len(plan))
+	var groups []*huh.Group
+	for i, entry := range plan {
+		edited[i] = CommitPlanEntry{Message: entry.Message, Files: entry.Files}
+
+		inCommit := make(map[string]bool, len(entry.Files))
+		for _, f := range entry.Files {
+			inCommit[f] = true
+		}
+		options := make([]huh.Option[string], len(allFiles))
+		for j, f := range allFiles {
+			options[j] = huh.NewOption(f, f).Selected(inCommit[f])
+		}
+
+		groups = append(groups, huh.NewGroup(
+			huh.NewText().
+				Title(fmt.Sprintf("Commit %d of %d: message", i+1, len(plan))).
+				CharLimit(1000).
+				Value(&edited[i].Message),
+			huh.NewMultiSelect[string]().
+				Title(fmt.Sprintf("Commit %d of %d: files", i+1, len(plan))).
+				Options(options...).
+				Value(&edited[i].Files).
+				Height(15),
+		))
+	}
+
+	if err := huh.NewForm(groups...).Run(); err != nil {
+		return nil, err
+	}
+
+	edited, _ = NormalizeCommitPlan(edited, allFiles)
+	if len(edited) == 0 {
+		fmt.Println("No commits left in the plan. Operation cancelled.")
+		return nil, errors.New("empty commit plan")
+	}
+	return edited, nil
+}
+
+// DisplayRewordPlan lists each commit's current subject next to the
+// proposed one
+func (h *InteractionService) DisplayRewordPlan(commits []CommitInfo, messages []string) {
+	width := 0
+	for _, commit := range commits {
+		width = max(width, len([]rune(commit.Subject())))
+	}
+	width = min(width, 50)
+
+	underline := color.New(color.Underline)
+	underline.Printf("Proposed messages for %d commits:\n\n", len(commits))
+	for i, commit := range commits {
+		old := []rune(commit.Subject())
+		if len(old) > width {
+			old = append(old[:width-1], '…')
+		}
+		subject, _, _ := strings.Cut(messages[i], "\n")
+		color.New(color.FgYellow).Printf("%s ", ShortHash(commit.Hash))
+		color.New(color.Faint).Printf("%-*s", width, string(old))
+		fmt.Print("  →  ")
+		color.New(color.Bold).Println(subject)
+	}
+	fmt.Println()
 }
 
+// ReviewRewordPlan asks the user to accept, edit, regenerate or cancel the
+// proposed messages
+func (h *InteractionService) ReviewRewordPlan(
+	commits []CommitInfo,
+	messages []string,
+	opts *CommitOptions,
+) (Action, []string, error) {
+	if *opts.NoConfirm {
+		return ActionConfirm, messages, nil
+	}
+
+	h.DisplayRewordPlan(commits, messages)
+
+	var selectedAction Action
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[Action]().
+				Title("Rewrite these commits?").
+				Options(
+					huh.NewOption("Yes", ActionConfirm),
+					huh.NewOption("Edit", ActionEdit),
+					huh.NewOption("Regenerate", ActionRegenerate),
+					huh.NewOption("Cancel", ActionCancel),
+				).
+				Value(&selectedAction),
+		),
+	).Run(); err != nil {
+		return "", nil, err
+	}
+
+	if selectedAction != ActionEdit {
+		return selectedAction, messages, nil
+	}
+
+	edited := append([]string(nil), messages...)
+	groups := make([]*huh.Group, len(commits))
+	for i, commit := range commits {
+		groups[i] = huh.NewGroup(
+			huh.NewText().
+				Title(fmt.Sprintf("Commit %d of %d (%s, was: %s)", i+1, len(commits), ShortHash(commit.Hash), commit.Subject())).
+				CharLimit(1000).
+				Validate(requireMessage).
+				Value(&edited[i]),
+		)
+	}
+	if err := huh.NewForm(groups...).Run(); err != nil {
+		return "", nil, err
+	}
+
+	rules := LoadLintRules(*opts.MaxLength)
+	for i := range edited {
+		edited[i] = strings.TrimSpace(edited[i])
+		if violations := LintCommitMessage(edited[i], rules); len(violations) > 0 {
+			color.New(color.FgYellow).Printf(
+				"⚠ Commit %d of %d violates Conventional Commits rules:\n%s\n",
+				i+1, len(edited), FormatLintViolations(violations),
+			)
+		}
+	}
+	return ActionEdit, edited, nil
+}
+
+// requireMessage rejects an empty or whitespace-only commit message.
+func requireMessage(message string) error {
+	if strings.TrimSpace(message) == "" {
+		return errors.New("the commit message cannot be empty")
+	}
+	return nil
+}
+
+// Confirm asks a yes/no question, defaulting to no
+func (h *InteractionService) Confirm(title string) (bool, error) {
+	confirmed := false
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().Title(title).Value(&confirmed),
+		),
+	).Run(); err != nil {
+		return false, err
+	}
+	return confirmed, nil
+}
+
+// ReviewBranchName asks whether to create the suggested branch. Edit returns
+// ActionConfirm with the edited name.
+func (h *InteractionService) ReviewBranchName(name string) (Action, string, error) {
+	color.New(color.Bold).Printf("%s\n\n", name)
+
+	var selectedAction Action
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[Action]().
+				Title("Create this branch?").
+				Options(
+					huh.NewOption("Yes", ActionConfirm),
+					huh.NewOption("Regenerate", ActionRegenerate),
+					huh.NewOption("Edit", ActionEdit),
+					huh.NewOption("Cancel", ActionCancel),
+				).
+				Value(&selectedAction),
+		),
+	).Run(); err != nil {
+		return "", "", err
+	}
+
+	if selectedAction != ActionEdit {
+		return selectedAction, name, nil
+	}
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Title("Edit branch name").Value(&name),
+		),
+	).Run(); err != nil {
+		return "", "", err
+	}
+	return ActionConfirm, strings.TrimSpace(name), nil
+}
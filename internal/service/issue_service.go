@@ -0,0 +1,236 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultIssueTemplate renders detected issues as a footer.
+const DefaultIssueTemplate = "Refs: {issues}"
+
+// defaultIssuePatterns are tried in order when issue.patterns is not set. A
+// project key must be upper case (chore/bump-node-18 is not NODE-18), and a
+// bare number only counts after a keyword, a # or at the start of a branch
+// segment followed by a word, so dates and versions are not taken as issues.
+var defaultIssuePatterns = []string{
+	// ABC-123
+	`\b(?P<key>(?P<project>[A-Z][A-Z0-9]+)-\d+)\b`,
+	// #123
+	`#(?P<key>\d+)`,
+	// issue-123, fix/123
+	`(?i)\b(?:issues?|gh|fix|feat|feature|bug|bugfix|hotfix)[-_/](?P<key>\d+)\b`,
+	// 123-feature
+	`(?:^|/)(?P<key>\d+)-[A-Za-z]`,
+}
+
+// caseFoldedKeyPattern replaces the ABC-123 default when issue.projects is
+// set: with an allowlist, abc-123 is safe to read as ABC-123.
+const caseFoldedKeyPattern = `(?i)\b(?P<key>(?P<project>[a-z][a-z0-9]+)-\d+)\b`
+
+// branchTypeWords are branch prefixes that look like project keys in
+// "fix-123" but are not.
+var branchTypeWords = []string{"ISSUE", "ISSUES", "GH", "FIX", "FEAT", "FEATURE", "BUG", "BUGFIX", "HOTFIX", "RELEASE"}
+
+var (
+	projectKeyRe     = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)-\d+$`)
+	issueRefLineRe   = regexp.MustCompile(`^(?i)([a-z-]+)(?::\s*|\s+)(.+)$`)
+	issueListSplitRe = regexp.MustCompile(`[,\s]+`)
+)
+
+// IssueConfig controls how issues are found in branch names and rendered in
+// commit messages.
+type IssueConfig struct {
+	Patterns []*regexp.Regexp
+	Projects []string // allowed project keys; empty allows any
+	Multiple bool     // keep every issue found instead of the first
+	Template string
+}
+
+// LoadIssueConfig reads issue.patterns, issue.projects, issue.multiple and
+// issue.template.
+func LoadIssueConfig() (IssueConfig, error) {
+	cfg := IssueConfig{
+		Template: DefaultIssueTemplate,
+		Multiple: viper.GetBool("issue.multiple"),
+	}
+
+	for _, project := range viper.GetStringSlice("issue.projects") {
+		cfg.Projects = append(cfg.Projects, strings.ToUpper(project))
+	}
+
+	patterns := defaultIssuePatterns
+	if custom := viper.GetStringSlice("issue.patterns"); len(custom) > 0 {
+		patterns = custom
+	} else if len(cfg.Projects) > 0 {
+		patterns = append([]string{caseFoldedKeyPattern}, defaultIssuePatterns[1:]...)
+	}
+	for _, raw := range patterns {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return cfg, Errorf(ErrCodeInvalidConfig, "invalid issue.patterns entry %q: %v", raw, err)
+		}
+		cfg.Patterns = append(cfg.Patterns, re)
+	}
+
+	if viper.IsSet("issue.template") {
+		cfg.Template = viper.GetString("issue.template")
+	}
+	if !strings.Contains(cfg.Template, "{issue}") && !strings.Contains(cfg.Template, "{issues}") {
+		return cfg, Errorf(ErrCodeInvalidConfig, "issue.template %q must contain {issue} or {issues}", cfg.Template)
+	}
+	return cfg, nil
+}
+
+// ExtractIssues finds issue keys in a branch name. Patterns are tried in
+// order; the "key" group (else the first group, else the whole match) is the
+// issue and the "project" group, or the prefix of an ABC-123 key, is checked
+// against the allowlist.
+func (c IssueConfig) ExtractIssues(branch string) []string {
+	var issues []string
+	for _, re := range c.Patterns {
+		keyIndex, projectIndex := re.SubexpIndex("key"), re.SubexpIndex("project")
+		if keyIndex < 0 && re.NumSubexp() > 0 {
+			keyIndex = 1
+		}
+		for _, match := range re.FindAllStringSubmatch(branch, -1) {
+			key := match[0]
+			if keyIndex >= 0 {
+				key = match[keyIndex]
+			}
+			project := ""
+			if projectIndex >= 0 {
+				project = match[projectIndex]
+			} else if m := projectKeyRe.FindStringSubmatch(key); m != nil {
+				project = m[1]
+			}
+			if key = NormalizeIssue(key); key == "" || !c.allowsProject(project) {
+				continue
+			}
+			issues = appendUnique(issues, key)
+			if !c.Multiple {
+				return issues
+			}
+		}
+	}
+	return issues
+}
+
+func (c IssueConfig) allowsProject(project string) bool {
+	if project == "" {
+		return true
+	}
+	project = strings.ToUpper(project)
+	if len(c.Projects) > 0 {
+		return slices.Contains(c.Projects, project)
+	}
+	return !slices.Contains(nonIssueKeys, project) && !slices.Contains(branchTypeWords, project)
+}
+
+// NormalizeIssue upper-cases ABC-123 keys and writes bare numbers as #123.
+func NormalizeIssue(issue string) string {
+	issue = strings.TrimSpace(issue)
+	switch {
+	case issue != "" && strings.Trim(issue, "0123456789") == "":
+		return "#" + issue
+	case projectKeyRe.MatchString(issue):
+		return strings.ToUpper(issue)
+	}
+	return issue
+}
+
+// ParseIssueList splits a comma-separated --issue value.
+func ParseIssueList(value string) []string {
+	var issues []string
+	for _, issue := range strings.Split(value, ",") {
+		issues = appendUnique(issues, NormalizeIssue(issue))
+	}
+	return issues
+}
+
+// Apply renders issues into message with the template. A template with
+// {subject} replaces the first line (e.g. "[{issues}] {subject}"); any other
+// template is added as footer lines, one per issue for {issue} or a single
+// line for {issues}. Existing reference lines for the same issues are
+// replaced, so applying twice gives the same message.
+func (c IssueConfig) Apply(message string, issues []string) string {
+	message = strings.TrimSpace(message)
+	if len(issues) == 0 || message == "" {
+		return message
+	}
+	joined := strings.Join(issues, ", ")
+
+	header, rest, _ := strings.Cut(message, "\n")
+	var kept []string
+	for _, line := range strings.Split(rest, "\n") {
+		if !isIssueReferenceLine(line, issues) {
+			kept = append(kept, line)
+		}
+	}
+	rest = strings.TrimSpace(strings.Join(kept, "\n"))
+
+	if strings.Contains(c.Template, "{subject}") {
+		prefix, _, _ := strings.Cut(c.Template, "{subject}")
+		if !strings.HasPrefix(header, renderIssueTemplate(prefix, joined)) {
+			header = strings.ReplaceAll(renderIssueTemplate(c.Template, joined), "{subject}", header)
+		}
+		if rest == "" {
+			return header
+		}
+		return header + "\n\n" + rest
+	}
+
+	var footers []string
+	if strings.Contains(c.Template, "{issue}") {
+		for _, issue := range issues {
+			footers = append(footers, renderIssueTemplate(c.Template, issue))
+		}
+	} else {
+		footers = append(footers, renderIssueTemplate(c.Template, joined))
+	}
+
+	message = header
+	if rest != "" {
+		message += "\n\n" + rest
+	}
+	separator := "\n\n"
+	if parsed, err := ParseConventionalCommit(message); err == nil && len(parsed.Footers) > 0 {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(footers, "\n")
+}
+
+func renderIssueTemplate(template, issue string) string {
+	return strings.NewReplacer("{issues}", issue, "{issue}", issue).Replace(template)
+}
+
+// isIssueReferenceLine reports whether line is a reference footer such as
+// "Refs: #12" or "Closes ABC-1" that mentions only issues.
+func isIssueReferenceLine(line string, issues []string) bool {
+	m := issueRefLineRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil || !slices.Contains(issueFooterTokens, strings.ToLower(m[1])) {
+		return false
+	}
+	for _, ref := range issueListSplitRe.Split(strings.TrimSpace(m[2]), -1) {
+		if !slices.Contains(issues, NormalizeIssue(ref)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyIssueReferences renders the comma-separated issue into message with
+// the configured template.
+func ApplyIssueReferences(message string, issue string) (string, error) {
+	issues := ParseIssueList(issue)
+	if len(issues) == 0 {
+		return message, nil
+	}
+	cfg, err := LoadIssueConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Apply(message, issues), nil
+}
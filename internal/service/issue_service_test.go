@@ -0,0 +1,35 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestExtractIssuesProjectKeyCase(t *testing.T) {
+	t.Cleanup(func() { viper.Set("issue", nil) })
+
+	cases := []struct {
+		projects []string
+		branch   string
+		want     []string
+	}{
+		{nil, "feat/ABC-12-login", []string{"ABC-12"}},
+		{nil, "feature/abc-123", nil},
+		{nil, "chore/bump-node-18", nil},
+		{[]string{"abc"}, "feature/abc-123", []string{"ABC-123"}},
+		{[]string{"abc"}, "feat/ABC-12-login", []string{"ABC-12"}},
+		{[]string{"abc"}, "chore/bump-node-18", nil},
+	}
+	for _, tc := range cases {
+		viper.Set("issue.projects", tc.projects)
+		cfg, err := LoadIssueConfig()
+		if err != nil {
+			t.Fatalf("LoadIssueConfig: %v", err)
+		}
+		if got := cfg.ExtractIssues(tc.branch); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("projects %v: ExtractIssues(%q) = %v, want %v", tc.projects, tc.branch, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Output formats accepted by --output.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Actions reported in Report.Action.
+const (
+	ReportActionCommitted = "committed"
+	ReportActionPushed    = "pushed"
+	ReportActionPRCreated = "pr_created"
+	ReportActionPRUpdated = "pr_updated"
+	ReportActionDryRun    = "dry_run"
+)
+
+// Report is the document printed by --output json once a command succeeds.
+type Report struct {
+	Command        string         `json:"command"`
+	Message        string         `json:"message"`
+	Files          []string       `json:"files"`
+	Issue          string         `json:"issue,omitempty"`
+	Provider       string         `json:"provider,omitempty"`
+	Model          string         `json:"model"`
+	Truncation     TruncationInfo `json:"truncation"`
+	Secrets        []SecretReport `json:"secrets"`
+	Action         string         `json:"action"`
+	Commit         string         `json:"commit,omitempty"`
+	PullRequestURL string         `json:"pull_request_url,omitempty"`
+}
+
+// TruncationInfo describes how the diff was shortened before it was sent.
+type TruncationInfo struct {
+	Truncated    bool     `json:"truncated"`
+	MaxDiffLines int      `json:"max_diff_lines,omitempty"`
+	Summarized   bool     `json:"summarized"`
+	OmittedFiles []string `json:"omitted_files"`
+}
+
+// SecretReport is a SecretFinding without the matched value.
+type SecretReport struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// ValidateOutput checks an --output value.
+func ValidateOutput(output string) error {
+	if output != OutputText && output != OutputJSON {
+		return Errorf(ErrCodeInvalidArgument, "invalid output format %q (expected %s or %s)", output, OutputText, OutputJSON)
+	}
+	return nil
+}
+
+// NewReport fills the parts of a Report that come from the prepared changes
+// and the provider that answered.
+func NewReport(command, message string, data *PreCommitData, opts *CommitOptions) *Report {
+	report := &Report{
+		Command: command,
+		Message: message,
+		Files:   nonNil(data.Files),
+		Issue:   data.Issue,
+		Model:   *opts.Model,
+		Truncation: TruncationInfo{
+			Truncated:    data.Truncated,
+			Summarized:   data.Summarized,
+			OmittedFiles: nonNil(data.Ignored),
+		},
+		Secrets: []SecretReport{},
+	}
+	if data.Truncated && opts.MaxDiffLines != nil {
+		report.Truncation.MaxDiffLines = *opts.MaxDiffLines
+	}
+	if p := LastUsedProvider(); p != nil {
+		report.Provider = p.Name
+		report.Model = p.Model
+	}
+	for _, f := range data.Secrets {
+		report.Secrets = append(report.Secrets, SecretReport{File: f.File, Line: f.Line, Rule: f.Rule})
+	}
+	return report
+}
+
+// WriteJSON writes v to w as an indented JSON document.
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON output: %v", err)
+	}
+	return nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+)
+
+//go:embed user_prompt.md
+var userPromptTemplate string
+
+//go:embed combined_user_prompt.md
+var combinedUserPromptTemplate string
+
+//go:embed split_user_prompt.md
+var splitUserPromptTemplate string
+
+// Prompt template names. A file <name>.md in a prompt directory overrides the
+// embedded default.
+const (
+	PromptSystem        = "system"
+	PromptUser          = "user"
+	PromptFileSelection = "file_selection"
+	PromptCombined      = "combined"
+	PromptCombinedUser  = "combined_user"
+	PromptSplit         = "split"
+	PromptSplitUser     = "split_user"
+)
+
+// PromptNames lists the overridable templates in display order.
+var PromptNames = []string{
+	PromptSystem, PromptUser, PromptFileSelection, PromptCombined, PromptCombinedUser, PromptSplit, PromptSplitUser,
+}
+
+// PromptSourceEmbedded is reported for templates that are not overridden.
+const PromptSourceEmbedded = "embedded"
+
+var embeddedPrompts = map[string]*string{
+	PromptSystem:        &systemPrompt,
+	PromptUser:          &userPromptTemplate,
+	PromptFileSelection: &fileSelectionPrompt,
+	PromptCombined:      &combinedPrompt,
+	PromptCombinedUser:  &combinedUserPromptTemplate,
+	PromptSplit:         &splitPrompt,
+	PromptSplitUser:     &splitUserPromptTemplate,
+}
+
+// PromptData is the data available to prompt templates.
+type PromptData struct {
+	Diff         string
+	Files        []string // changed files
+	RelatedFiles []string // neighboring files, as "dir/file, ..."
+	Context      string
+	Issue        string
+	IssueDetails string // title and description fetched from the issue tracker
+	Branch       string
+	Language     string
+	MaxLength    int
+	Style        string // style guidance learned from history, appended to system prompts
+}
+
+var promptFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// PromptDirs returns the directories searched for prompt templates, highest
+// priority first: .opencommit/prompts in the repository, then prompts/ next
+// to the user config file in use (so --config moves it too).
+func PromptDirs() []string {
+	var dirs []string
+	if root, err := NewGitService().GetRepoRoot(); err == nil {
+		dirs = append(dirs, filepath.Join(root, ".opencommit", "prompts"))
+	}
+	if configFile := viper.ConfigFileUsed(); configFile != "" {
+		dirs = append(dirs, filepath.Join(filepath.Dir(configFile), "prompts"))
+	} else if config, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(config, "opencommit", "prompts"))
+	}
+	return dirs
+}
+
+// LoadPromptTemplate returns the template text for name and where it was
+// found: a file path, or PromptSourceEmbedded.
+func LoadPromptTemplate(name string) (string, string, error) {
+	embedded, ok := embeddedPrompts[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown prompt %q (use %s)", name, strings.Join(PromptNames, ", "))
+	}
+	for _, dir := range PromptDirs() {
+		path := filepath.Join(dir, name+".md")
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return string(content), path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", fmt.Errorf("failed to read prompt template %s: %v", path, err)
+		}
+	}
+	return *embedded, PromptSourceEmbedded, nil
+}
+
+// RenderPrompt renders the named template with data.
+func RenderPrompt(name string, data *PromptData) (string, error) {
+	text, source, err := LoadPromptTemplate(name)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template %s: %v", source, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %v", source, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// RenderRequestPrompt renders name as it is sent to the model: commit
+// system prompts get the language and length requirements appended.
+func RenderRequestPrompt(name string, data *PromptData) (string, error) {
+	text, err := RenderPrompt(name, data)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case PromptSystem, PromptCombined:
+		text = withRequirements(text, data)
+	case PromptSplit:
+		text = withSplitRequirements(text, data)
+	}
+	return text, nil
+}
+
+// diffFiles lists the files named in a diff's section headers.
+func diffFiles(diff string) []string {
+	_, sections := SplitDiffSections(diff)
+	files := make([]string, 0, len(sections))
+	for _, section := range sections {
+		if path := DiffSectionPath(section); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// currentBranch returns the checked-out branch name, or "" when unknown.
+func currentBranch() string {
+	branch, err := NewGitService().GetCurrentBranchName()
+	if err != nil || branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
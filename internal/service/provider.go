@@ -2,60 +2,283 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/spf13/viper"
 )
 
+// Provider types accepted in api.type, api2.type and [[providers]].type.
+const (
+	ProviderTypeOpenAI    = "openai"
+	ProviderTypeAnthropic = "anthropic"
+)
+
+// Ordering policies accepted in api.order.
+const (
+	ProviderOrderStatic      = "static"
+	ProviderOrderLastSuccess = "last_success"
+	ProviderOrderRoundRobin  = "round_robin"
+)
+
+// Names given to the legacy [api] and [api2] providers.
+const (
+	legacyPrimaryName   = "api1"
+	legacySecondaryName = "api2"
+)
+
 // ProviderConfig describes one AI provider candidate.
 type ProviderConfig struct {
-	ID      int // 1 = primary (api.*), 2 = secondary (api2.*)
-	Key     string
-	BaseURL string
+	Name    string `mapstructure:"name"` // api1/api2 for the legacy sections
+	Type    string `mapstructure:"type"`
+	Key     string `mapstructure:"key"`
+	BaseURL string `mapstructure:"baseurl"`
+	Model   string `mapstructure:"model"`
+}
+
+// ProviderOverride carries explicitly-set CLI flags (--model, --baseurl).
+// Non-empty fields replace those of the first declared provider.
+type ProviderOverride struct {
 	Model   string
+	BaseURL string
 }
 
-// BuildProviders returns the ordered provider list. `primary` is the
-// fully-resolved api1 configuration (already merged with CLI flags by the
-// caller). The secondary is loaded from viper (api2.*) and api.last_success
-// determines order when both providers have a key.
-//
-// Providers with an empty Key are filtered out; the returned slice may be
-// empty, in which case the caller should report a missing-key error.
-func BuildProviders(primary ProviderConfig) []ProviderConfig {
-	primary.ID = 1
-	if primary.Model == "" {
-		primary.Model = DefaultModel
+// chatRequest is the provider-neutral shape of a single completion request.
+type chatRequest struct {
+	Model        string
+	SystemPrompt string
+	UserPrompt   string
+	Temperature  float32
+	MaxTokens    int
+}
+
+// chatClient is implemented by each wire protocol. complete and stream each
+// perform exactly one request; retries and fallback are layered on top by the
+// callers. stream calls onDelta for every text fragment as it arrives and
+// returns the concatenated text.
+type chatClient interface {
+	complete(ctx context.Context, req chatRequest) (string, error)
+	stream(ctx context.Context, req chatRequest, onDelta func(string)) (string, error)
+}
+
+// openAIClient adapts go-openai to chatClient.
+type openAIClient struct {
+	client *openai.Client
+}
+
+func (c *openAIClient) request(r chatRequest) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model: r.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: r.SystemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: r.UserPrompt,
+			},
+		},
+		Temperature: r.Temperature,
+		MaxTokens:   r.MaxTokens,
 	}
+}
 
-	secondary := ProviderConfig{
-		ID:      2,
-		Key:     viper.GetString("api2.key"),
-		BaseURL: viper.GetString("api2.baseurl"),
-		Model:   viper.GetString("api2.model"),
+func (c *openAIClient) complete(ctx context.Context, r chatRequest) (string, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.request(r))
+	if err != nil {
+		return "", err
 	}
-	if secondary.Model == "" {
-		secondary.Model = DefaultModel
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from AI model")
 	}
+	return resp.Choices[0].Message.Content, nil
+}
 
-	var providers []ProviderConfig
-	if primary.Key != "" {
-		providers = append(providers, primary)
+func (c *openAIClient) stream(ctx context.Context, r chatRequest, onDelta func(string)) (string, error) {
+	stream, err := c.client.CreateChatCompletionStream(ctx, c.request(r))
+	if err != nil {
+		return "", err
 	}
-	if secondary.Key != "" {
-		providers = append(providers, secondary)
+	defer stream.Close()
+
+	var text strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return text.String(), nil
+		}
+		if err != nil {
+			return text.String(), err
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		if delta := resp.Choices[0].Delta.Content; delta != "" {
+			text.WriteString(delta)
+			onDelta(delta)
+		}
 	}
+}
 
-	if len(providers) == 2 && viper.GetInt("api.last_success") == 2 {
-		providers[0], providers[1] = providers[1], providers[0]
+// ResolveProviderType returns the effective protocol for p. An empty type
+// defaults to openai, except when the base URL points at api.anthropic.com.
+func ResolveProviderType(p ProviderConfig) (string, error) {
+	t := strings.ToLower(strings.TrimSpace(p.Type))
+	switch t {
+	case "":
+		if u, err := url.Parse(p.BaseURL); err == nil && u.Hostname() == "api.anthropic.com" {
+			return ProviderTypeAnthropic, nil
+		}
+		return ProviderTypeOpenAI, nil
+	case ProviderTypeOpenAI, ProviderTypeAnthropic:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown provider type '%s' (use %s or %s)", p.Type, ProviderTypeOpenAI, ProviderTypeAnthropic)
 	}
+}
 
+// ConfiguredProviders returns every declared provider in declaration order,
+// with the override applied to the first one. When the config has a
+// [[providers]] array it defines the chain and the legacy [api]/[api2]
+// sections are ignored. No filtering or reordering is done here.
+func ConfiguredProviders(override ProviderOverride) []ProviderConfig {
+	providers, _ := configuredProviders(override)
 	return providers
 }
 
+func configuredProviders(override ProviderOverride) ([]ProviderConfig, bool) {
+	var entries []ProviderConfig
+	if err := viper.UnmarshalKey("providers", &entries); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring invalid [[providers]] config: %v\n", err)
+		entries = nil
+	}
+
+	var providers []ProviderConfig
+	fromList := len(entries) > 0
+	if fromList {
+		for i, p := range entries {
+			if p.Name == "" {
+				p.Name = fmt.Sprintf("provider%d", i+1)
+			}
+			providers = append(providers, p)
+		}
+	} else {
+		providers = []ProviderConfig{
+			{
+				Name:    legacyPrimaryName,
+				Type:    viper.GetString("api.type"),
+				Key:     viper.GetString("api.key"),
+				BaseURL: viper.GetString("api.baseurl"),
+				Model:   viper.GetString("api.model"),
+			},
+			{
+				Name:    legacySecondaryName,
+				Type:    viper.GetString("api2.type"),
+				Key:     viper.GetString("api2.key"),
+				BaseURL: viper.GetString("api2.baseurl"),
+				Model:   viper.GetString("api2.model"),
+			},
+		}
+	}
+
+	if override.Model != "" {
+		providers[0].Model = override.Model
+	}
+	if override.BaseURL != "" {
+		providers[0].BaseURL = override.BaseURL
+	}
+	for i := range providers {
+		if providers[i].Model == "" {
+			providers[i].Model = DefaultModel
+		}
+	}
+
+	return providers, fromList
+}
+
+// BuildProviders returns the ordered provider chain that chatCompleteFallback
+// walks. Legacy [api]/[api2] providers need a key; [[providers]] entries need
+// either a key or a base URL (local servers such as Ollama take no key).
+// The order follows api.order, using api.last_provider as the cursor.
+//
+// The returned slice may be empty, in which case the caller should report a
+// missing-key error.
+func BuildProviders(override ProviderOverride) []ProviderConfig {
+	declared, fromList := configuredProviders(override)
+
+	var providers []ProviderConfig
+	for _, p := range declared {
+		if p.Key != "" || (fromList && p.BaseURL != "") {
+			providers = append(providers, p)
+		}
+	}
+
+	return orderProviders(providers, viper.GetString("api.order"), lastProviderName())
+}
+
+// lastProviderName reads api.last_provider, falling back to the integer
+// api.last_success written by older versions.
+func lastProviderName() string {
+	if name := viper.GetString("api.last_provider"); name != "" {
+		return name
+	}
+	if viper.GetInt("api.last_success") == 2 {
+		return legacySecondaryName
+	}
+	return ""
+}
+
+// orderProviders applies an ordering policy to the declared chain:
+//   - static:       declaration order
+//   - last_success: the last successful provider first, the rest in order
+//   - round_robin:  start with the provider after the last successful one
+func orderProviders(providers []ProviderConfig, order string, last string) []ProviderConfig {
+	idx := -1
+	for i, p := range providers {
+		if p.Name == last {
+			idx = i
+			break
+		}
+	}
+
+	switch order {
+	case ProviderOrderStatic:
+		return providers
+	case ProviderOrderRoundRobin:
+		if idx < 0 {
+			return providers
+		}
+		start := (idx + 1) % len(providers)
+		return append(append([]ProviderConfig{}, providers[start:]...), providers[:start]...)
+	default:
+		if idx <= 0 {
+			return providers
+		}
+		ordered := []ProviderConfig{providers[idx]}
+		ordered = append(ordered, providers[:idx]...)
+		return append(ordered, providers[idx+1:]...)
+	}
+}
+
+// newChatClient builds the transport matching the provider's type.
+func newChatClient(p ProviderConfig) (chatClient, error) {
+	providerType, err := ResolveProviderType(p)
+	if err != nil {
+		return nil, err
+	}
+	if providerType == ProviderTypeAnthropic {
+		return newAnthropicClient(p), nil
+	}
+	return &openAIClient{client: newOpenAIClient(p)}, nil
+}
+
 // newOpenAIClient builds a configured openai client for a single provider.
 func newOpenAIClient(p ProviderConfig) *openai.Client {
 	cfg := openai.DefaultConfig(p.Key)
@@ -65,22 +288,65 @@ func newOpenAIClient(p ProviderConfig) *openai.Client {
 	return openai.NewClientWithConfig(cfg)
 }
 
-// persistLastSuccess writes api.last_success to the config file when the value
-// has changed. Write errors are reported on stderr but not returned — losing
-// the hint is not fatal.
-func persistLastSuccess(usedID int) {
-	if viper.GetInt("api.last_success") == usedID {
+// PingProvider sends a minimal request to a single provider without retry or
+// fallback. It is used by `config test`.
+func PingProvider(ctx context.Context, p ProviderConfig) error {
+	client, err := newChatClient(p)
+	if err != nil {
+		return err
+	}
+	_, err = client.complete(ctx, chatRequest{
+		Model:      p.Model,
+		UserPrompt: "ping",
+		MaxTokens:  5,
+	})
+	return err
+}
+
+// persistMu serializes config writes from concurrent requests (e.g. the
+// per-file summaries of SummarizeDiff).
+var persistMu sync.Mutex
+
+// persistLastSuccess writes api.last_provider to the config file when the
+// value has changed. Write errors are reported on stderr but not returned —
+// losing the hint is not fatal.
+func persistLastSuccess(name string) {
+	persistMu.Lock()
+	defer persistMu.Unlock()
+
+	if viper.GetString("api.last_provider") == name {
 		return
 	}
-	viper.Set("api.last_success", usedID)
-	if err := viper.WriteConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to persist api.last_success: %v\n", err)
+	viper.Set("api.last_provider", name)
+	if err := UpdateConfigFile(viper.ConfigFileUsed(), "api.last_provider", name); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to persist api.last_provider: %v\n", err)
 	}
 }
 
+// lastUsedProvider is the provider that served the latest successful
+// request, guarded by persistMu.
+var lastUsedProvider *ProviderConfig
+
+// recordProviderSuccess remembers p for LastUsedProvider and persists it as
+// api.last_provider.
+func recordProviderSuccess(p ProviderConfig) {
+	persistMu.Lock()
+	lastUsedProvider = &p
+	persistMu.Unlock()
+	persistLastSuccess(p.Name)
+}
+
+// LastUsedProvider returns the provider that served the latest successful
+// request in this process, or nil when none has succeeded yet.
+func LastUsedProvider() *ProviderConfig {
+	persistMu.Lock()
+	defer persistMu.Unlock()
+	return lastUsedProvider
+}
+
 // chatCompleteFallback runs the same chat-completion request against an ordered
 // list of providers, falling back to the next one on error. It records
-// api.last_success when a provider succeeds.
+// api.last_provider when a provider succeeds.
 func chatCompleteFallback(
 	ctx context.Context,
 	providers []ProviderConfig,
@@ -88,7 +354,7 @@ func chatCompleteFallback(
 	userPrompt string,
 ) (string, error) {
 	if len(providers) == 0 {
-		return "", fmt.Errorf("no AI providers configured")
+		return "", Errorf(ErrCodeNoProvider, "no AI providers configured")
 	}
 
 	var errs []string
@@ -96,13 +362,56 @@ func chatCompleteFallback(
 		if err := ctx.Err(); err != nil {
 			return "", err
 		}
-		client := newOpenAIClient(p)
+		client, err := newChatClient(p)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
 		text, err := chatCompleteOnce(client, ctx, p.Model, systemPrompt, userPrompt)
 		if err == nil {
-			persistLastSuccess(p.ID)
+			recordProviderSuccess(p)
+			return text, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name, err))
+	}
+	return "", Errorf(ErrCodeAIRequestFailed, "all providers failed (in order): %s", strings.Join(errs, "; "))
+}
+
+// chatCompleteStreamFallback is the streaming counterpart of
+// chatCompleteFallback. A provider that fails before emitting its first token
+// is retried and then skipped like in the blocking path; once text has been
+// handed to onDelta the error is returned as-is, because the partial output
+// has already been shown.
+func chatCompleteStreamFallback(
+	ctx context.Context,
+	providers []ProviderConfig,
+	systemPrompt string,
+	userPrompt string,
+	onDelta func(string),
+) (string, error) {
+	if len(providers) == 0 {
+		return "", Errorf(ErrCodeNoProvider, "no AI providers configured")
+	}
+
+	var errs []string
+	for _, p := range providers {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		client, err := newChatClient(p)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
+		text, started, err := chatStreamOnce(client, ctx, p.Model, systemPrompt, userPrompt, onDelta)
+		if err == nil {
+			recordProviderSuccess(p)
 			return text, nil
 		}
-		errs = append(errs, fmt.Sprintf("api%d: %v", p.ID, err))
+		if started {
+			return "", Errorf(ErrCodeAIRequestFailed, "%s: stream interrupted: %v", p.Name, err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name, err))
 	}
-	return "", fmt.Errorf("all providers failed (in order): %s", strings.Join(errs, "; "))
+	return "", Errorf(ErrCodeAIRequestFailed, "all providers failed (in order): %s", strings.Join(errs, "; "))
 }
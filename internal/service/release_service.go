@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed release_prompt.md
+var releasePrompt string
+
+// DefaultTagPrefix is used for the first release when release.tag_prefix is
+// not set.
+const DefaultTagPrefix = "v"
+
+var semverTagRe = regexp.MustCompile(`^([A-Za-z-]*?)(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// SemVer is a semantic version; build metadata is ignored.
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+	Pre   string
+}
+
+// ParseSemVerTag parses a tag such as v1.2.3 or 1.2.3-rc.1 into its prefix
+// and version.
+func ParseSemVerTag(tag string) (string, SemVer, bool) {
+	m := semverTagRe.FindStringSubmatch(tag)
+	if m == nil {
+		return "", SemVer{}, false
+	}
+	major, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	patch, _ := strconv.Atoi(m[4])
+	return m[1], SemVer{Major: major, Minor: minor, Patch: patch, Pre: m[5]}, true
+}
+
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare orders versions by semver precedence: -1, 0 or 1.
+func (v SemVer) Compare(o SemVer) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		switch {
+		case errX == nil && errY == nil:
+			return sign(x - y)
+		case errX == nil:
+			return -1
+		case errY == nil:
+			return 1
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return sign(len(a) - len(b))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Bump is the size of a version increment.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// Bump names accepted by release --bump.
+var BumpNames = map[string]Bump{"patch": BumpPatch, "minor": BumpMinor, "major": BumpMajor}
+
+func (b Bump) String() string {
+	switch b {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	case BumpPatch:
+		return "patch"
+	}
+	return "none"
+}
+
+// ReleaseBump derives the increment from Conventional Commits: major for
+// breaking changes, minor for feat, patch for fix, perf and revert.
+func ReleaseBump(commits []CommitInfo) Bump {
+	bump := BumpNone
+	for _, commit := range commits {
+		parsed, err := ParseConventionalCommit(commit.Message)
+		if err != nil {
+			continue
+		}
+		switch {
+		case parsed.Breaking:
+			return BumpMajor
+		case strings.EqualFold(parsed.Type, "feat"):
+			bump = max(bump, BumpMinor)
+		case slices.Contains([]string{"fix", "perf", "revert"}, strings.ToLower(parsed.Type)):
+			bump = max(bump, BumpPatch)
+		}
+	}
+	return bump
+}
+
+// Apply increments v, dropping any pre-release.
+func (b Bump) Apply(v SemVer) SemVer {
+	switch b {
+	case BumpMajor:
+		return SemVer{Major: v.Major + 1}
+	case BumpMinor:
+		return SemVer{Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// NextVersion bumps current and, for a pre-release channel, appends
+// <channel>.<n> numbered after the existing pre-releases of that version.
+func NextVersion(current SemVer, bump Bump, channel string, existing []SemVer) SemVer {
+	next := bump.Apply(current)
+	if channel == "" {
+		return next
+	}
+	n := 0
+	for _, v := range existing {
+		if v.Major != next.Major || v.Minor != next.Minor || v.Patch != next.Patch {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(v.Pre, channel+"."); ok {
+			if i, err := strconv.Atoi(rest); err == nil && i > n {
+				n = i
+			}
+		}
+	}
+	next.Pre = fmt.Sprintf("%s.%d", channel, n+1)
+	return next
+}
+
+// SummarizeRelease asks the AI for the body of an annotated tag message
+// from the release's changelog.
+func (a *AIService) SummarizeRelease(
+	providers []ProviderConfig,
+	ctx context.Context,
+	changelog string,
+	language string,
+) (string, error) {
+	systemPrompt := releasePrompt
+	if language != "" && language != "english" {
+		systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Write the message in %s language.", language)
+	}
+	result, err := chatCompleteFallback(ctx, providers, systemPrompt, changelog)
+	if err != nil {
+		return "", err
+	}
+	result = strings.TrimSpace(strings.ReplaceAll(result, "```", ""))
+	if result == "" {
+		return "", Errorf(ErrCodeEmptyMessage, "no release summary was generated. try again")
+	}
+	return result, nil
+}
+
+// ListMergedTags returns the tags reachable from HEAD.
+func (g *GitService) ListMergedTags() ([]string, error) {
+	output, err := exec.Command("git", "tag", "--merged", "HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// ListTags returns every tag in the repository.
+func (g *GitService) ListTags() ([]string, error) {
+	output, err := exec.Command("git", "tag").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// CreateAnnotatedTag tags HEAD with name and message.
+func (g *GitService) CreateAnnotatedTag(name, message string) error {
+	cmd := exec.Command("git", "tag", "--annotate", "--cleanup=whitespace", "--file=-", name)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return Errorf(ErrCodeCommitFailed, "failed to create tag %s: %v: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// PushTag pushes a single tag to remoteName.
+func (g *GitService) PushTag(remoteName, name string, quiet *bool) error {
+	cmd := exec.Command("git", "push", remoteName, "refs/tags/"+name)
+	if !*quiet {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return Errorf(ErrCodePushFailed, "failed to push tag %s to %s: %v", name, remoteName, err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// RepoConfigFileName is the per-repository config file, looked up at the
+// root of the working tree and layered over the user config.
+const RepoConfigFileName = ".opencommit.toml"
+
+// Config layers reported by ConfigSource.
+const (
+	ConfigLayerDefault = "default"
+	ConfigLayerUser    = "user"
+	ConfigLayerRepo    = "repo"
+)
+
+// repoForbiddenFields are key segments that must never come from a file that
+// is committed with the repository: credentials, and endpoints a hostile
+// repository could use to receive the user's credentials.
+var repoForbiddenFields = map[string]bool{
+	"key":     true,
+	"baseurl": true,
+	"token":   true,
+	"api_url": true,
+}
+
+// repoForbiddenKeys are whole keys or tables that stay user-only: besides
+// credentials, the safety settings a repository must not be able to turn off
+// for whoever clones it (the secret guard, hooks, confirmation and pushing).
+var repoForbiddenKeys = []string{
+	"api.last_provider",
+	"providers",
+	"forges",
+	"secret",
+	"behavior.push",
+	"behavior.no_verify",
+	"behavior.no_confirm",
+}
+
+// IsRepoConfigKeyAllowed reports whether key may be set in .opencommit.toml.
+func IsRepoConfigKeyAllowed(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range repoForbiddenKeys {
+		if key == k || strings.HasPrefix(key, k+".") {
+			return false
+		}
+	}
+	for _, part := range strings.Split(key, ".") {
+		if repoForbiddenFields[part] {
+			return false
+		}
+	}
+	return true
+}
+
+// RepoIgnorePatterns keeps the ignore.patterns a repository may set: it can
+// leave more files out of the prompt, but `!` re-includes are dropped so it
+// cannot send files the user has excluded. dropped reports whether any were.
+func RepoIgnorePatterns(patterns []string) (kept []string, dropped bool) {
+	for _, p := range patterns {
+		if strings.HasPrefix(strings.TrimSpace(p), "!") {
+			dropped = true
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, dropped
+}
+
+// RepoConfigPath returns where .opencommit.toml lives for the current
+// repository, whether or not the file exists.
+func RepoConfigPath() (string, error) {
+	root, err := NewGitService().GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, RepoConfigFileName), nil
+}
+
+// ReadConfigFile loads a single TOML config file into its own viper
+// instance. A missing file yields an empty config.
+func ReadConfigFile(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return v, nil
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateConfigFile sets key in the file at path, leaving its other values
+// as they are. Only that file is written, so values merged in from other
+// layers never leak into it.
+func UpdateConfigFile(path, key string, value interface{}) error {
+	v, err := ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	v.Set(key, value)
+	return v.WriteConfigAs(path)
+}
+
+// LoadRepoConfig merges the repository's .opencommit.toml, if any, over the
+// config viper has already read. It returns the file that was merged ("" when
+// there is none) and the keys that were ignored because they are user-only.
+func LoadRepoConfig() (string, []string, error) {
+	path, err := RepoConfigPath()
+	if err != nil {
+		return "", nil, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	repo, err := ReadConfigFile(path)
+	if err != nil {
+		return path, nil, err
+	}
+
+	allowed := make(map[string]interface{})
+	var ignored []string
+	for _, key := range repo.AllKeys() {
+		if !IsRepoConfigKeyAllowed(key) {
+			ignored = append(ignored, key)
+			continue
+		}
+		if key == "ignore.patterns" {
+			// Add to the user's patterns rather than replacing them.
+			patterns, dropped := RepoIgnorePatterns(repo.GetStringSlice(key))
+			if dropped {
+				ignored = append(ignored, "! patterns in ignore.patterns")
+			}
+			setNested(allowed, key, append(viper.GetStringSlice(key), patterns...))
+			continue
+		}
+		setNested(allowed, key, repo.Get(key))
+	}
+	sort.Strings(ignored)
+	if err := viper.MergeConfigMap(allowed); err != nil {
+		return path, ignored, err
+	}
+	return path, ignored, nil
+}
+
+// ConfigSource names the layer the effective value of key comes from.
+// user and repo may be nil when that layer has no file.
+func ConfigSource(key string, user, repo *viper.Viper) string {
+	if repo != nil && repo.IsSet(key) && IsRepoConfigKeyAllowed(key) {
+		return ConfigLayerRepo
+	}
+	if user != nil && user.IsSet(key) {
+		return ConfigLayerUser
+	}
+	return ConfigLayerDefault
+}
+
+// setNested stores value under a dotted key in a nested settings map.
+func setNested(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// Secret policies accepted in secret.policy.
+const (
+	SecretPolicyRedact = "redact"
+	SecretPolicyWarn   = "warn"
+	SecretPolicyAbort  = "abort"
+	SecretPolicyOff    = "off"
+)
+
+const (
+	secretEntropyRule      = "high-entropy-string"
+	secretPrivateKeyRule   = "private-key"
+	secretEntropyThreshold = 4.0
+	secretEntropyMinLength = 20
+)
+
+// SecretRule is a named pattern for a credential format. When the pattern
+// has a capture group, only the first group is reported and redacted.
+type SecretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// builtinSecretRules cover common provider key formats.
+var builtinSecretRules = []SecretRule{
+	{"aws-access-key-id", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{"aws-secret-access-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})`)},
+	{"gitlab-token", regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20,})`)},
+	{"slack-token", regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{"anthropic-api-key", regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{20,})`)},
+	{"openai-api-key", regexp.MustCompile(`\b(sk-(?:proj-)?[A-Za-z0-9_-]{20,})`)},
+	{"google-api-key", regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`)},
+	{"stripe-key", regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{16,})`)},
+	{"jwt", regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`)},
+	{"url-credentials", regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^\s:/@]+:([^\s:/@]{3,})@`)},
+	{"secret-assignment", regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api_?key|access_?key|client_?secret)[A-Za-z0-9_]*["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)},
+}
+
+var (
+	privateKeyBeginRe  = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEndRe    = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	envAssignmentRe    = regexp.MustCompile(`^\s*(?:export\s+)?[A-Za-z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_KEY|APIKEY|PRIVATE_KEY|CREDENTIALS?)[A-Za-z0-9_]*\s*=\s*["']?([^"'\s#]+)`)
+	entropyCandidateRe = regexp.MustCompile(`[A-Za-z0-9+/_=-]{20,}`)
+	hunkNewStartRe     = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+	secretRuleNameRe   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// defaultSecretAllowPaths are files full of hashes that look like secrets.
+var defaultSecretAllowPaths = []string{"go.sum", "*.lock", "package-lock.json", "pnpm-lock.yaml", "*.svg"}
+
+// SecretScanConfig is the secret.* configuration.
+type SecretScanConfig struct {
+	Policy    string
+	Rules     []SecretRule
+	Allowlist []*regexp.Regexp // matched against the secret and the file path
+}
+
+// SecretFinding is a suspected secret on an added line.
+type SecretFinding struct {
+	File  string
+	Line  int // line number in the new version of the file
+	Rule  string
+	Match string
+}
+
+// LoadSecretScanConfig reads secret.policy, secret.rules and secret.allowlist.
+// Custom rules are `name=regex` or a bare regex.
+func LoadSecretScanConfig() (SecretScanConfig, error) {
+	cfg := SecretScanConfig{
+		Policy: SecretPolicyRedact,
+		Rules:  append([]SecretRule(nil), builtinSecretRules...),
+	}
+	if viper.IsSet("secret.policy") {
+		cfg.Policy = strings.ToLower(viper.GetString("secret.policy"))
+	}
+	switch cfg.Policy {
+	case SecretPolicyRedact, SecretPolicyWarn, SecretPolicyAbort, SecretPolicyOff:
+	default:
+		return cfg, fmt.Errorf("invalid secret.policy %q (use redact, warn, abort or off)", cfg.Policy)
+	}
+
+	for i, raw := range viper.GetStringSlice("secret.rules") {
+		name, pattern := fmt.Sprintf("custom-%d", i+1), raw
+		if n, p, ok := strings.Cut(raw, "="); ok && secretRuleNameRe.MatchString(n) {
+			name, pattern = n, p
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid secret.rules entry %q: %v", raw, err)
+		}
+		cfg.Rules = append(cfg.Rules, SecretRule{Name: name, Pattern: re})
+	}
+	for _, raw := range viper.GetStringSlice("secret.allowlist") {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid secret.allowlist entry %q: %v", raw, err)
+		}
+		cfg.Allowlist = append(cfg.Allowlist, re)
+	}
+	return cfg, nil
+}
+
+// ScanDiffForSecrets checks the added lines of diff. It returns the findings
+// and a copy of diff with every finding replaced by a redaction marker.
+func ScanDiffForSecrets(diff string, cfg SecretScanConfig) ([]SecretFinding, string) {
+	var findings []SecretFinding
+	lines := strings.Split(diff, "\n")
+	file, lineNo := "", 0
+	inHeader, inPrivateKey := false, false
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, diffSectionSep):
+			file, lineNo = DiffSectionPath(line), 0
+			inHeader, inPrivateKey = true, false
+			continue
+		case strings.HasPrefix(line, "@@"):
+			if m := hunkNewStartRe.FindStringSubmatch(line); m != nil {
+				lineNo, _ = strconv.Atoi(m[1])
+			}
+			inHeader = false
+			continue
+		case inHeader:
+			continue
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			continue
+		case !strings.HasPrefix(line, "+"):
+			lineNo++
+			continue
+		}
+
+		current := lineNo
+		lineNo++
+		if secretPathAllowed(file, cfg) {
+			continue
+		}
+		content := line[1:]
+
+		// Private keys are redacted as a whole block.
+		if inPrivateKey || privateKeyBeginRe.MatchString(content) {
+			if !inPrivateKey {
+				findings = append(findings, SecretFinding{File: file, Line: current, Rule: secretPrivateKeyRule, Match: privateKeyBeginRe.FindString(content)})
+			}
+			inPrivateKey = !privateKeyEndRe.MatchString(content)
+			lines[i] = "+[REDACTED:" + secretPrivateKeyRule + "]"
+			continue
+		}
+
+		redacted := content
+		report := func(rule, match string) {
+			// Skip values another rule has already redacted.
+			if match == "" || !strings.Contains(redacted, match) || secretAllowed(match, cfg) {
+				return
+			}
+			findings = append(findings, SecretFinding{File: file, Line: current, Rule: rule, Match: match})
+			redacted = strings.ReplaceAll(redacted, match, "[REDACTED:"+rule+"]")
+		}
+
+		for _, rule := range cfg.Rules {
+			for _, m := range rule.Pattern.FindAllStringSubmatch(content, -1) {
+				match := m[0]
+				if len(m) > 1 && m[1] != "" {
+					match = m[1]
+				}
+				report(rule.Name, match)
+			}
+		}
+		if isEnvFile(file) {
+			if m := envAssignmentRe.FindStringSubmatch(redacted); m != nil && !strings.HasPrefix(m[1], "[REDACTED") {
+				report("env-assignment", m[1])
+			}
+		}
+		for _, candidate := range entropyCandidateRe.FindAllString(redacted, -1) {
+			if looksHighEntropy(candidate) {
+				report(secretEntropyRule, candidate)
+			}
+		}
+
+		lines[i] = "+" + redacted
+	}
+	return findings, strings.Join(lines, "\n")
+}
+
+// GuardDiffSecrets applies the configured secret policy to a diff about to be
+// sent to an AI provider. It reports findings on stderr and returns the diff
+// to send, or an error when the policy is abort.
+func GuardDiffSecrets(diff string) (string, error) {
+	diff, _, err := GuardDiffSecretsTo(os.Stderr, diff)
+	return diff, err
+}
+
+// GuardDiffSecretsTo is GuardDiffSecrets reporting findings to w instead;
+// a nil w reports nothing. It also returns the findings.
+func GuardDiffSecretsTo(w io.Writer, diff string) (string, []SecretFinding, error) {
+	cfg, err := LoadSecretScanConfig()
+	if err != nil {
+		return "", nil, WithCode(ErrCodeInvalidConfig, err)
+	}
+	if cfg.Policy == SecretPolicyOff || diff == "" {
+		return diff, nil, nil
+	}
+
+	findings, redacted := ScanDiffForSecrets(diff, cfg)
+	if len(findings) == 0 {
+		return diff, nil, nil
+	}
+
+	report := func(c *color.Color, format string) {
+		if w != nil {
+			c.Fprintf(w, format, FormatSecretFindings(findings))
+		}
+	}
+	warn := color.New(color.FgYellow)
+	switch cfg.Policy {
+	case SecretPolicyAbort:
+		report(color.New(color.FgRed), "✖ Possible secrets in the diff:\n%s\n")
+		return "", findings, Errorf(ErrCodeSecretsDetected, "refusing to send the diff to the AI provider: %d possible secret(s) found (secret.policy is abort)", len(findings))
+	case SecretPolicyWarn:
+		report(warn, "⚠ Possible secrets in the diff (sent unchanged, secret.policy is warn):\n%s\n")
+		return diff, findings, nil
+	default:
+		report(warn, "⚠ Possible secrets redacted before sending the diff:\n%s\n")
+		return redacted, findings, nil
+	}
+}
+
+// FormatSecretFindings renders findings as an indented list with the
+// matched values masked.
+func FormatSecretFindings(findings []SecretFinding) string {
+	var b strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&b, "  %s:%d  %s  %s\n", f.File, f.Line, f.Rule, maskSecret(f.Match))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func maskSecret(s string) string {
+	if len(s) <= 8 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:4] + strings.Repeat("*", min(len(s)-4, 12))
+}
+
+func secretAllowed(match string, cfg SecretScanConfig) bool {
+	for _, re := range cfg.Allowlist {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func secretPathAllowed(file string, cfg SecretScanConfig) bool {
+	base := path.Base(file)
+	for _, pattern := range defaultSecretAllowPaths {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return secretAllowed(file, cfg)
+}
+
+func isEnvFile(file string) bool {
+	base := path.Base(file)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// looksHighEntropy flags long mixed-alphabet tokens whose Shannon entropy is
+// typical of generated keys rather than identifiers, paths or hex hashes.
+func looksHighEntropy(s string) bool {
+	if len(s) < secretEntropyMinLength {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !(upper && lower && digit) {
+		return false
+	}
+	return shannonEntropy(s) >= secretEntropyThreshold
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
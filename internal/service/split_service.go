@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:embed split_prompt.md
+var splitPrompt string
+
+// CommitPlanEntry is one commit of a split plan.
+type CommitPlanEntry struct {
+	Files   []string `json:"files"`
+	Message string   `json:"message"`
+}
+
+// PlanCommits asks the AI to partition all changed files into an ordered
+// list of atomic commits. The plan is normalized against files: unknown paths
+// are dropped, a path listed twice stays in its first commit, and commits left
+// without files are removed. Files the model left out are returned as
+// unplanned.
+func (a *AIService) PlanCommits(
+	providers []ProviderConfig,
+	ctx context.Context,
+	diff string,
+	files []string,
+	opts *SelectFilesAndGenerateCommitOptions,
+) ([]CommitPlanEntry, []string, error) {
+	if opts == nil || opts.MaxLength == nil || opts.Language == nil {
+		return nil, nil, fmt.Errorf("MaxLength and Language are required")
+	}
+
+	data := newPromptData(diff, nil, opts.UserContext, opts.MaxLength, opts.Language, opts.Issue)
+	data.Files = files
+	enhancedSystemPrompt, err := RenderRequestPrompt(PromptSplit, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	prompt, err := RenderRequestPrompt(PromptSplitUser, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := chatCompleteFallback(ctx, providers, enhancedSystemPrompt, prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan, err := parseCommitPlan(result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan, unplanned := NormalizeCommitPlan(plan, files)
+	if len(plan) == 0 {
+		return nil, nil, fmt.Errorf("AI returned a plan without any known files. Response was: %s", result)
+	}
+
+	for i := range plan {
+		plan[i].Message = a.enforceCommitRules(providers, ctx, plan[i].Message, diff, nil, opts)
+		if opts.Issue != nil {
+			if plan[i].Message, err = ApplyIssueReferences(plan[i].Message, *opts.Issue); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return plan, unplanned, nil
+}
+
+// withSplitRequirements appends the language and length requirements to a
+// rendered split system prompt.
+func withSplitRequirements(systemPrompt string, data *PromptData) string {
+	if data.Language != "english" {
+		systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Generate the commit messages in %s language.", data.Language)
+	}
+	systemPrompt += fmt.Sprintf("\n\nIMPORTANT: Keep each commit message under %d characters.", data.MaxLength)
+	return systemPrompt
+}
+
+// parseCommitPlan extracts the JSON array from a model response, tolerating
+// code fences and surrounding prose.
+func parseCommitPlan(result string) ([]CommitPlanEntry, error) {
+	start := strings.Index(result, "[")
+	end := strings.LastIndex(result, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("AI response did not include a commit plan in expected format. Response was: %s", result)
+	}
+
+	var plan []CommitPlanEntry
+	if err := json.Unmarshal([]byte(result[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("AI response included an invalid commit plan: %v. Response was: %s", err, result)
+	}
+
+	for i := range plan {
+		msg := strings.ReplaceAll(plan[i].Message, "```", "")
+		plan[i].Message = strings.TrimSpace(msg)
+	}
+	return plan, nil
+}
+
+// NormalizeCommitPlan restricts plan to the given files, keeps each file in
+// the first commit that lists it and drops commits without files or message.
+// It returns the cleaned plan and the files no commit claims.
+func NormalizeCommitPlan(plan []CommitPlanEntry, files []string) ([]CommitPlanEntry, []string) {
+	known := make(map[string]bool, len(files))
+	for _, f := range files {
+		known[f] = true
+	}
+
+	claimed := make(map[string]bool, len(files))
+	var cleaned []CommitPlanEntry
+	for _, entry := range plan {
+		var entryFiles []string
+		for _, f := range entry.Files {
+			f = strings.Trim(f, "` \t\n\r")
+			if !known[f] || claimed[f] {
+				continue
+			}
+			claimed[f] = true
+			entryFiles = append(entryFiles, f)
+		}
+		if len(entryFiles) == 0 || entry.Message == "" {
+			for _, f := range entryFiles {
+				delete(claimed, f)
+			}
+			continue
+		}
+		cleaned = append(cleaned, CommitPlanEntry{Files: entryFiles, Message: entry.Message})
+	}
+
+	var unplanned []string
+	for _, f := range files {
+		if !claimed[f] {
+			unplanned = append(unplanned, f)
+		}
+	}
+	return cleaned, unplanned
+}
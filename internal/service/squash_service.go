@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const squashContextBodyLines = 10
+
+// issueFooterTokens are the footers that reference issues and must survive
+// a squash.
+var issueFooterTokens = []string{"closes", "close", "fixes", "fix", "resolves", "resolve", "refs", "ref", "issue", "issues", "related", "see"}
+
+// nonIssueKeys look like ABC-123 issue keys but are not (UTF-8, SHA-256...).
+var nonIssueKeys = []string{"UTF", "SHA", "ISO", "RFC", "TLS", "SSL", "HTTP", "AES", "MD", "PEP", "ES"}
+
+// SquashTrailers is what a squash message has to keep from the commits it
+// replaces.
+type SquashTrailers struct {
+	Breaking     []string // BREAKING CHANGE descriptions
+	IssueFooters []string // footers such as "Closes #12"
+	Refs         []string // other issue references found in messages
+}
+
+// CollectSquashTrailers gathers breaking changes and issue references from
+// commits, oldest first.
+func CollectSquashTrailers(commits []CommitInfo) *SquashTrailers {
+	trailers := &SquashTrailers{}
+	for _, commit := range commits {
+		parsed, err := ParseConventionalCommit(commit.Message)
+		if err != nil {
+			trailers.addRefs(commit.Message)
+			continue
+		}
+
+		breakingFooter := false
+		for _, footer := range parsed.Footers {
+			switch {
+			case footer.Token == "BREAKING CHANGE" || footer.Token == "BREAKING-CHANGE":
+				breakingFooter = true
+				trailers.Breaking = appendUnique(trailers.Breaking, strings.TrimSpace(footer.Value))
+			case slices.Contains(issueFooterTokens, strings.ToLower(footer.Token)):
+				trailers.IssueFooters = appendUnique(trailers.IssueFooters, formatFooter(footer))
+			}
+		}
+		if parsed.Breaking && !breakingFooter {
+			trailers.Breaking = appendUnique(trailers.Breaking, parsed.Description)
+		}
+		trailers.addRefs(parsed.Header + "\n" + parsed.Body)
+	}
+
+	// References already carried by an issue footer need no Refs line.
+	var refs []string
+	for _, ref := range trailers.Refs {
+		if !slices.ContainsFunc(trailers.IssueFooters, func(f string) bool { return strings.Contains(f, ref) }) {
+			refs = append(refs, ref)
+		}
+	}
+	trailers.Refs = refs
+	return trailers
+}
+
+func (t *SquashTrailers) addRefs(text string) {
+	for _, ref := range ticketRefRe.FindAllString(text, -1) {
+		if key, _, _ := strings.Cut(ref, "-"); !slices.Contains(nonIssueKeys, key) {
+			t.Refs = appendUnique(t.Refs, ref)
+		}
+	}
+}
+
+// Apply appends the breaking changes and issue references message lacks as
+// footers.
+func (t *SquashTrailers) Apply(message string) string {
+	message = strings.TrimSpace(message)
+
+	var footers []string
+	if !strings.Contains(message, "BREAKING CHANGE") && !strings.Contains(message, "BREAKING-CHANGE") {
+		for _, breaking := range t.Breaking {
+			footers = append(footers, "BREAKING CHANGE: "+breaking)
+		}
+	}
+	for _, footer := range t.IssueFooters {
+		_, value, _ := strings.Cut(footer, " ")
+		if !strings.Contains(message, strings.TrimSpace(value)) {
+			footers = append(footers, footer)
+		}
+	}
+	var refs []string
+	for _, ref := range t.Refs {
+		if !strings.Contains(message, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) > 0 {
+		footers = append(footers, "Refs: "+strings.Join(refs, ", "))
+	}
+	if len(footers) == 0 {
+		return message
+	}
+
+	separator := "\n\n"
+	if parsed, err := ParseConventionalCommit(message); err == nil && len(parsed.Footers) > 0 {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(footers, "\n")
+}
+
+// SquashContext describes the commits being squashed for the prompt.
+func SquashContext(commits []CommitInfo) string {
+	var b strings.Builder
+	b.WriteString("These commits are being squashed into one; write a single message for their combined change:\n")
+	for _, commit := range commits {
+		subject, body, _ := strings.Cut(commit.Message, "\n")
+		fmt.Fprintf(&b, "- %s\n", subject)
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		if len(lines) > squashContextBodyLines {
+			lines = append(lines[:squashContextBodyLines], "...")
+		}
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				fmt.Fprintf(&b, "    %s\n", line)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// formatFooter renders a footer, restoring the `Token #123` form for bare
+// issue numbers.
+func formatFooter(footer CommitFooter) string {
+	value := strings.TrimSpace(footer.Value)
+	if value != "" && strings.Trim(value, "0123456789") == "" {
+		return footer.Token + " #" + value
+	}
+	return footer.Token + ": " + value
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" || slices.Contains(list, value) {
+		return list
+	}
+	return append(list, value)
+}
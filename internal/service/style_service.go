@@ -0,0 +1,331 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	styleExampleCount    = 4
+	styleScopeCount      = 10
+	styleExampleBodyLine = 6
+)
+
+var (
+	ticketRefRe     = regexp.MustCompile(`[A-Z][A-Z0-9]+-\d+|#\d+`)
+	gitmojiRe       = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+	styleSkipPrefix = []string{"Merge ", "Revert \"", "fixup!", "squash!", "amend!"}
+)
+
+// StyleProfile summarizes the commit conventions of a repository, derived
+// from a sample of its history. Counts are out of Commits.
+type StyleProfile struct {
+	Head          string   `json:"head"`
+	SampleSize    int      `json:"sample_size"`
+	Commits       int      `json:"commits"`
+	Conventional  int      `json:"conventional"`
+	Scopes        []string `json:"scopes"`
+	Capitalized   int      `json:"capitalized"`
+	PastTense     int      `json:"past_tense"`
+	FullStop      int      `json:"full_stop"`
+	Emoji         int      `json:"emoji"`
+	WithBody      int      `json:"with_body"`
+	TicketPrefix  int      `json:"ticket_prefix"`
+	TicketSuffix  int      `json:"ticket_suffix"`
+	TicketExample string   `json:"ticket_example"`
+	Examples      []string `json:"examples"`
+}
+
+// BuildStyleProfile infers a StyleProfile from commit messages, newest first.
+// Merges, reverts and fixups are ignored.
+func BuildStyleProfile(messages []string) *StyleProfile {
+	profile := &StyleProfile{}
+	scopeCounts := make(map[string]int)
+	var sample []string
+
+	for _, message := range messages {
+		subject, body, _ := strings.Cut(message, "\n")
+		subject = strings.TrimSpace(subject)
+		if subject == "" || hasAnyPrefix(subject, styleSkipPrefix) {
+			continue
+		}
+		sample = append(sample, message)
+		profile.Commits++
+
+		description := subject
+		if commit, err := ParseConventionalCommit(message); err == nil {
+			profile.Conventional++
+			description = commit.Description
+			for _, scope := range strings.Split(commit.Scope, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					scopeCounts[scope]++
+				}
+			}
+		}
+
+		if startsWithEmoji(subject) {
+			profile.Emoji++
+		}
+		if loc := ticketRefRe.FindStringIndex(subject); loc != nil {
+			if profile.TicketExample == "" {
+				profile.TicketExample = subject[loc[0]:loc[1]]
+			}
+			if loc[0] <= 1 {
+				profile.TicketPrefix++
+			} else if loc[1] >= len(subject)-1 {
+				profile.TicketSuffix++
+			}
+		}
+
+		description = strings.TrimSpace(ticketRefRe.ReplaceAllString(description, ""))
+		description = strings.TrimLeft(description, "[]():- ")
+		if r, _ := utf8.DecodeRuneInString(description); unicode.IsUpper(r) {
+			profile.Capitalized++
+		}
+		if word, _, _ := strings.Cut(description, " "); len(word) > 3 && strings.HasSuffix(strings.ToLower(word), "ed") {
+			profile.PastTense++
+		}
+		if strings.HasSuffix(subject, ".") {
+			profile.FullStop++
+		}
+		if strings.TrimSpace(body) != "" {
+			profile.WithBody++
+		}
+	}
+
+	profile.Scopes = topScopes(scopeCounts, styleScopeCount)
+	profile.Examples = pickStyleExamples(sample, profile)
+	return profile
+}
+
+// PromptSection renders the profile as guidance for the system prompt.
+// Conventions that the enabled lint rules already decide (subject case and
+// the trailing period) are left out so the prompt never contradicts what
+// RepairCommitMessage enforces afterwards.
+func (p *StyleProfile) PromptSection(lint LintRules) string {
+	if p == nil || p.Commits == 0 {
+		return ""
+	}
+	majority := func(n int) bool { return n*2 > p.Commits }
+
+	var rules []string
+	if majority(p.Conventional) {
+		rules = append(rules, fmt.Sprintf("%d of the last %d commits use Conventional Commits headers", p.Conventional, p.Commits))
+	} else {
+		rules = append(rules, fmt.Sprintf("only %d of the last %d commits use Conventional Commits headers", p.Conventional, p.Commits))
+	}
+	if len(p.Scopes) > 0 {
+		rules = append(rules, "prefer these existing scopes: "+strings.Join(p.Scopes, ", "))
+	}
+	if !lint.Enabled || lint.SubjectCase == SubjectCaseAny {
+		if majority(p.Capitalized) {
+			rules = append(rules, "subjects start with a capital letter")
+		} else {
+			rules = append(rules, "subjects start with a lowercase letter")
+		}
+	}
+	if majority(p.PastTense) {
+		rules = append(rules, "subjects use the past tense")
+	} else {
+		rules = append(rules, "subjects use the imperative mood")
+	}
+	if majority(p.FullStop) && (!lint.Enabled || !lint.SubjectFullStop) {
+		rules = append(rules, "subjects end with a period")
+	}
+	if majority(p.Emoji) {
+		rules = append(rules, "subjects start with an emoji")
+	}
+	if majority(p.TicketPrefix) {
+		rules = append(rules, fmt.Sprintf("subjects start with a ticket reference such as %s", p.TicketExample))
+	} else if majority(p.TicketSuffix) {
+		rules = append(rules, fmt.Sprintf("subjects end with a ticket reference such as %s", p.TicketExample))
+	}
+	if majority(p.WithBody) {
+		rules = append(rules, "most commits have a body explaining the change")
+	}
+
+	var b strings.Builder
+	b.WriteString("Match the commit style of this repository:\n")
+	for _, rule := range rules {
+		b.WriteString("- " + rule + "\n")
+	}
+	if len(p.Examples) > 0 {
+		b.WriteString("\nRecent commits from this repository, for reference only:\n")
+		for _, example := range p.Examples {
+			b.WriteString("\n```\n" + example + "\n```\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// LoadStyleProfile returns the style profile for the current repository per
+// the style.* config, using the on-disk cache while the sampled branch has
+// not moved. It returns nil when profiling is disabled or there is no
+// history to learn from.
+func LoadStyleProfile() *StyleProfile {
+	if viper.IsSet("style.enabled") && !viper.GetBool("style.enabled") {
+		return nil
+	}
+	sampleSize := DefaultStyleSampleSize
+	if viper.IsSet("style.sample_size") {
+		sampleSize = viper.GetInt("style.sample_size")
+	}
+	if sampleSize <= 0 {
+		return nil
+	}
+	rev := viper.GetString("style.branch")
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	git := NewGitService()
+	head, err := git.ResolveRevision(rev)
+	if err != nil {
+		return nil
+	}
+
+	cachePath := styleCachePath()
+	if cached := readStyleCache(cachePath); cached != nil && cached.Head == head && cached.SampleSize == sampleSize {
+		return cached
+	}
+
+	messages, err := git.GetLastCommitMessages(sampleSize, head)
+	if err != nil || len(messages) == 0 {
+		return nil
+	}
+	profile := BuildStyleProfile(messages)
+	profile.Head = head
+	profile.SampleSize = sampleSize
+	writeStyleCache(cachePath, profile)
+	return profile
+}
+
+// styleCachePath returns the cache file for the current repository, or ""
+// when it cannot be determined.
+func styleCachePath() string {
+	root, err := NewGitService().GetRepoRoot()
+	if err != nil {
+		return ""
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cacheDir, "opencommit", "style", hex.EncodeToString(sum[:8])+".json")
+}
+
+func readStyleCache(path string) *StyleProfile {
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var profile StyleProfile
+	if err := json.Unmarshal(content, &profile); err != nil {
+		return nil
+	}
+	return &profile
+}
+
+// writeStyleCache stores profile; failures only cost a recomputation.
+func writeStyleCache(path string, profile *StyleProfile) {
+	if path == "" {
+		return
+	}
+	content, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, content, 0o644)
+}
+
+// pickStyleExamples chooses a few recent messages that follow the majority
+// format and case, preferring different types and scopes, with long bodies
+// shortened.
+func pickStyleExamples(messages []string, profile *StyleProfile) []string {
+	conventional := profile.Conventional*2 > profile.Commits
+	capitalized := profile.Capitalized*2 > profile.Commits
+	seen := make(map[string]bool)
+	var examples []string
+	for _, message := range messages {
+		if len(examples) == styleExampleCount {
+			break
+		}
+		commit, err := ParseConventionalCommit(message)
+		if conventional != (err == nil) {
+			continue
+		}
+		key := ""
+		if commit != nil {
+			if r, _ := utf8.DecodeRuneInString(commit.Description); unicode.IsUpper(r) != capitalized {
+				continue
+			}
+			key = commit.Type + "(" + commit.Scope + ")"
+		}
+		if key != "" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		examples = append(examples, shortenExample(message))
+	}
+	return examples
+}
+
+func shortenExample(message string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	if len(lines) > styleExampleBodyLine {
+		lines = append(lines[:styleExampleBodyLine], "...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func topScopes(counts map[string]int, limit int) []string {
+	scopes := make([]string, 0, len(counts))
+	for scope := range counts {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if counts[scopes[i]] != counts[scopes[j]] {
+			return counts[scopes[i]] > counts[scopes[j]]
+		}
+		return scopes[i] < scopes[j]
+	})
+	if len(scopes) > limit {
+		scopes = scopes[:limit]
+	}
+	return scopes
+}
+
+func startsWithEmoji(subject string) bool {
+	if gitmojiRe.MatchString(subject) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(subject)
+	return r > 0x2000 && (unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r))
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+)
+
+//go:embed diff_summary_prompt.md
+var diffSummaryPrompt string
+
+// summaryFallbackLines is how much of a section is kept verbatim when its
+// summary request fails.
+const summaryFallbackLines = 20
+
+// EstimateTokens gives a rough token count (~4 characters per token), which
+// is close enough for budgeting prompts across providers.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// SummarizeDiff is the map-reduce path for diffs that do not fit in the
+// token budget. Each per-file section is summarized in its own request (the
+// map step, run on at most `workers` goroutines) and the summaries are joined
+// into a replacement diff for the regular commit-message prompt (the reduce
+// step). Diffs already within budget are returned unchanged with
+// summarized == false.
+func (a *AIService) SummarizeDiff(
+	providers []ProviderConfig,
+	ctx context.Context,
+	diff string,
+	tokenBudget int,
+	workers int,
+) (string, bool, error) {
+	if tokenBudget <= 0 || EstimateTokens(diff) <= tokenBudget {
+		return diff, false, nil
+	}
+	if workers <= 0 {
+		workers = DefaultSummaryWorkers
+	}
+
+	_, sections := SplitDiffSections(diff)
+	if len(sections) == 0 {
+		return truncateToTokens(diff, tokenBudget), true, nil
+	}
+
+	summaries := make([]string, len(sections))
+	errs := make([]error, len(sections))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func(i int, section string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// A single section never gets more than the whole budget as input.
+			section = truncateToTokens(section, tokenBudget)
+			summaries[i], errs[i] = chatCompleteFallback(ctx, providers, diffSummaryPrompt, section)
+		}(i, section)
+	}
+	wg.Wait()
+
+	failed := 0
+	var out strings.Builder
+	out.WriteString("NOTE: the diff was too large to send in full. Each file below is summarized separately.\n\n")
+	for i, section := range sections {
+		path := DiffSectionPath(section)
+		fmt.Fprintf(&out, "### %s\n", path)
+		if errs[i] != nil {
+			failed++
+			out.WriteString(truncateBlock(section, summaryFallbackLines))
+		} else {
+			out.WriteString(strings.TrimSpace(summaries[i]))
+		}
+		out.WriteString("\n\n")
+	}
+	if failed == len(sections) {
+		return "", false, fmt.Errorf("failed to summarize diff: %v", errs[0])
+	}
+
+	return truncateToTokens(strings.TrimSpace(out.String()), tokenBudget), true, nil
+}
+
+// truncateToTokens cuts s so that EstimateTokens(s) stays within budget.
+func truncateToTokens(s string, budget int) string {
+	maxChars := budget * 4
+	if len(s) <= maxChars {
+		return s
+	}
+	return cutAtLine(s, maxChars) + "\n... [truncated to fit the token budget]"
+}
+
+// SummarizeIfNeeded applies SummarizeDiff to data.Diff when summarize mode is
+// enabled, showing a spinner unless quiet.
+func (a *AIService) SummarizeIfNeeded(
+	providers []ProviderConfig,
+	ctx context.Context,
+	data *PreCommitData,
+	opts *CommitOptions,
+) error {
+	if opts.Summarize == nil || !*opts.Summarize {
+		return nil
+	}
+
+	budget := DefaultTokenBudget
+	if opts.TokenBudget != nil {
+		budget = *opts.TokenBudget
+	}
+	workers := DefaultSummaryWorkers
+	if opts.SummaryWorkers != nil {
+		workers = *opts.SummaryWorkers
+	}
+
+	var diff string
+	var summarized bool
+	var err error
+	run := func() {
+		diff, summarized, err = a.SummarizeDiff(providers, ctx, data.Diff, budget, workers)
+	}
+
+	if !*opts.Quiet && EstimateTokens(data.Diff) > budget {
+		if spinErr := spinner.New().
+			Title(fmt.Sprintf("Diff exceeds %d tokens, summarizing each file. (Model: %s)", budget, *opts.Model)).
+			Action(run).
+			Run(); spinErr != nil {
+			return spinErr
+		}
+	} else {
+		run()
+	}
+	if err != nil {
+		return err
+	}
+
+	if summarized && !*opts.Quiet {
+		color.New(color.FgYellow).Printf(
+			"⚠ Diff summarized per file to fit a %d token budget\n",
+			budget,
+		)
+	}
+	data.Diff = diff
+	data.Summarized = summarized
+	return nil
+}
+
+// cutAtLine shortens s to at most maxBytes, ending at the last complete line
+// that fits, or at a rune boundary when even the first line is too long.
+func cutAtLine(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	if i := strings.LastIndexByte(s[:maxBytes], '\n'); i > 0 {
+		return s[:i]
+	}
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
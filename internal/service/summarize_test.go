@@ -0,0 +1,32 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateToTokens(t *testing.T) {
+	const marker = "\n... [truncated to fit the token budget]"
+
+	// One long line of 3-byte runes: 10 tokens allow 40 bytes, which falls
+	// in the middle of the 14th rune.
+	line := strings.Repeat("日本語", 20)
+	got := truncateToTokens(line, 10)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncateToTokens produced invalid UTF-8: %q", got)
+	}
+	if want := strings.Repeat("日本語", 4) + "日" + marker; got != want {
+		t.Errorf("truncateToTokens = %q, want %q", got, want)
+	}
+
+	// With several lines the cut falls after the last complete line.
+	lines := "+ café\n+ naïve\n+ " + strings.Repeat("ü", 40)
+	if got := truncateToTokens(lines, 5); got != "+ café\n+ naïve"+marker {
+		t.Errorf("truncateToTokens = %q, want the complete lines only", got)
+	}
+
+	if got := truncateToTokens("short", 10); got != "short" {
+		t.Errorf("truncateToTokens = %q, want it unchanged", got)
+	}
+}
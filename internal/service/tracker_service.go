@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// Issue trackers accepted by tracker.type.
+const (
+	TrackerGitHub = "github"
+	TrackerGitLab = "gitlab"
+	TrackerJira   = "jira"
+	TrackerLinear = "linear"
+)
+
+const (
+	// DefaultTrackerMaxChars caps the issue text added to the prompt.
+	DefaultTrackerMaxChars = 2000
+	// DefaultTrackerCacheTTL is how long fetched issues are reused, in minutes.
+	DefaultTrackerCacheTTL = 60
+
+	trackerTimeout = 10 * time.Second
+)
+
+// trackerTokenEnv is read when tracker.token is not set.
+var trackerTokenEnv = map[string]string{
+	TrackerGitHub: "GITHUB_TOKEN",
+	TrackerGitLab: "GITLAB_TOKEN",
+	TrackerJira:   "JIRA_API_TOKEN",
+	TrackerLinear: "LINEAR_API_KEY",
+}
+
+var (
+	issueNumberRe  = regexp.MustCompile(`^#?(\d+)$`)
+	issueKeyRe     = regexp.MustCompile(`^[A-Z][A-Z0-9]+-\d+$`)
+	issueDetailsMu sync.Mutex
+	issueDetails   = map[string]string{}
+)
+
+// IssueDetails is what a tracker knows about an issue.
+type IssueDetails struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+}
+
+// IssueFetcher loads an issue from a tracker. It returns nil details and no
+// error for keys that do not belong to the tracker, such as ABC-123 for
+// GitHub or #12 for Jira.
+type IssueFetcher interface {
+	FetchIssue(ctx context.Context, key string) (*IssueDetails, error)
+}
+
+// GitHubFetcher reads GitHub Issues. Project is "owner/repo".
+type GitHubFetcher struct {
+	BaseURL string
+	Token   string
+	Project string
+	Client  *http.Client
+}
+
+func (f *GitHubFetcher) FetchIssue(ctx context.Context, key string) (*IssueDetails, error) {
+	m := issueNumberRe.FindStringSubmatch(key)
+	if m == nil {
+		return nil, nil
+	}
+	var issue struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/issues/%s", f.BaseURL, f.Project, m[1])
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	if f.Token != "" {
+		headers["Authorization"] = "Bearer " + f.Token
+	}
+	if err := apiRequest(ctx, f.Client, http.MethodGet, endpoint, headers, nil, &issue); err != nil {
+		return nil, err
+	}
+	return &IssueDetails{Key: "#" + m[1], Title: issue.Title, Body: issue.Body, URL: issue.HTMLURL}, nil
+}
+
+// GitLabFetcher reads GitLab issues. Project is the path, e.g. "group/repo".
+type GitLabFetcher struct {
+	BaseURL string
+	Token   string
+	Project string
+	Client  *http.Client
+}
+
+func (f *GitLabFetcher) FetchIssue(ctx context.Context, key string) (*IssueDetails, error) {
+	m := issueNumberRe.FindStringSubmatch(key)
+	if m == nil {
+		return nil, nil
+	}
+	var issue struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		WebURL      string `json:"web_url"`
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/issues/%s", f.BaseURL, url.PathEscape(f.Project), m[1])
+	headers := map[string]string{}
+	if f.Token != "" {
+		headers["PRIVATE-TOKEN"] = f.Token
+	}
+	if err := apiRequest(ctx, f.Client, http.MethodGet, endpoint, headers, nil, &issue); err != nil {
+		return nil, err
+	}
+	return &IssueDetails{Key: "#" + m[1], Title: issue.Title, Body: issue.Description, URL: issue.WebURL}, nil
+}
+
+// JiraFetcher reads Jira issues. With Email set the token is a Jira Cloud
+// API token used with basic auth; otherwise it is a personal access token.
+type JiraFetcher struct {
+	BaseURL string
+	Token   string
+	Email   string
+	Client  *http.Client
+}
+
+func (f *JiraFetcher) FetchIssue(ctx context.Context, key string) (*IssueDetails, error) {
+	if !issueKeyRe.MatchString(key) {
+		return nil, nil
+	}
+	var issue struct {
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+		} `json:"fields"`
+	}
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,description", f.BaseURL, url.PathEscape(key))
+	headers := map[string]string{"Accept": "application/json"}
+	switch {
+	case f.Email != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(f.Email+":"+f.Token))
+	case f.Token != "":
+		headers["Authorization"] = "Bearer " + f.Token
+	}
+	if err := apiRequest(ctx, f.Client, http.MethodGet, endpoint, headers, nil, &issue); err != nil {
+		return nil, err
+	}
+	return &IssueDetails{
+		Key:   key,
+		Title: issue.Fields.Summary,
+		Body:  issue.Fields.Description,
+		URL:   f.BaseURL + "/browse/" + key,
+	}, nil
+}
+
+// LinearFetcher reads Linear issues through the GraphQL API.
+type LinearFetcher struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+func (f *LinearFetcher) FetchIssue(ctx context.Context, key string) (*IssueDetails, error) {
+	if !issueKeyRe.MatchString(key) {
+		return nil, nil
+	}
+	query, err := json.Marshal(map[string]interface{}{
+		"query":     `query($id: String!) { issue(id: $id) { identifier title description url } }`,
+		"variables": map[string]string{"id": key},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data struct {
+			Issue *struct {
+				Identifier  string `json:"identifier"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				URL         string `json:"url"`
+			} `json:"issue"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	headers := map[string]string{"Content-Type": "application/json", "Authorization": f.Token}
+	if err := apiRequest(ctx, f.Client, http.MethodPost, f.BaseURL+"/graphql", headers, query, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("linear: %s", response.Errors[0].Message)
+	}
+	if response.Data.Issue == nil {
+		return nil, fmt.Errorf("linear: issue %s not found", key)
+	}
+	issue := response.Data.Issue
+	return &IssueDetails{Key: issue.Identifier, Title: issue.Title, Body: issue.Description, URL: issue.URL}, nil
+}
+
+// apiRequest sends a JSON API request and decodes the response into out.
+// Error responses are reported with the API's message when it has one.
+func apiRequest(
+	ctx context.Context,
+	client *http.Client,
+	method string,
+	endpoint string,
+	headers map[string]string,
+	body []byte,
+	out interface{},
+) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: trackerTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message interface{} `json:"message"`
+			Error   interface{} `json:"error"` // Bitbucket: {"error": {"message": ...}}
+		}
+		if json.Unmarshal(content, &apiErr) == nil {
+			if nested, ok := apiErr.Error.(map[string]interface{}); ok && apiErr.Message == nil {
+				apiErr.Message = nested["message"]
+			}
+			if apiErr.Message != nil {
+				return fmt.Errorf("%s %s: %s: %v", method, endpoint, resp.Status, apiErr.Message)
+			}
+		}
+		return fmt.Errorf("%s %s: %s", method, endpoint, resp.Status)
+	}
+	return json.Unmarshal(content, out)
+}
+
+// TrackerConfig is the [tracker] config.
+type TrackerConfig struct {
+	Type     string
+	BaseURL  string
+	Token    string
+	Project  string
+	Email    string
+	MaxChars int
+	CacheTTL time.Duration
+}
+
+// LoadTrackerConfig reads the tracker.* keys. Type is empty when no tracker
+// is configured.
+func LoadTrackerConfig() (TrackerConfig, error) {
+	cfg := TrackerConfig{
+		Type:     strings.ToLower(viper.GetString("tracker.type")),
+		BaseURL:  strings.TrimRight(viper.GetString("tracker.baseurl"), "/"),
+		Token:    viper.GetString("tracker.token"),
+		Project:  viper.GetString("tracker.project"),
+		Email:    viper.GetString("tracker.email"),
+		MaxChars: DefaultTrackerMaxChars,
+		CacheTTL: DefaultTrackerCacheTTL * time.Minute,
+	}
+	if viper.IsSet("tracker.max_chars") {
+		cfg.MaxChars = viper.GetInt("tracker.max_chars")
+	}
+	if viper.IsSet("tracker.cache_ttl") {
+		cfg.CacheTTL = time.Duration(viper.GetInt("tracker.cache_ttl")) * time.Minute
+	}
+	if cfg.Type == "" {
+		return cfg, nil
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv(trackerTokenEnv[cfg.Type])
+	}
+
+	switch cfg.Type {
+	case TrackerGitHub, TrackerGitLab:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = map[string]string{TrackerGitHub: "https://api.github.com", TrackerGitLab: "https://gitlab.com"}[cfg.Type]
+		}
+		if cfg.Project == "" {
+			cfg.Project = remoteProjectPath()
+		}
+		if cfg.Project == "" {
+			return cfg, Errorf(ErrCodeInvalidConfig, "tracker.project is not set and could not be read from the remote URL")
+		}
+	case TrackerJira:
+		if cfg.BaseURL == "" {
+			return cfg, Errorf(ErrCodeInvalidConfig, "tracker.baseurl is required for jira, e.g. https://example.atlassian.net")
+		}
+	case TrackerLinear:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://api.linear.app"
+		}
+		if cfg.Token == "" {
+			return cfg, Errorf(ErrCodeInvalidConfig, "tracker.token is required for linear")
+		}
+	default:
+		return cfg, Errorf(ErrCodeInvalidConfig, "invalid tracker.type %q (use github, gitlab, jira or linear)", cfg.Type)
+	}
+	return cfg, nil
+}
+
+// Fetcher returns the fetcher for the configured tracker, or nil when none is
+// configured.
+func (c TrackerConfig) Fetcher() IssueFetcher {
+	client := &http.Client{Timeout: trackerTimeout}
+	switch c.Type {
+	case TrackerGitHub:
+		return &GitHubFetcher{BaseURL: c.BaseURL, Token: c.Token, Project: c.Project, Client: client}
+	case TrackerGitLab:
+		return &GitLabFetcher{BaseURL: c.BaseURL, Token: c.Token, Project: c.Project, Client: client}
+	case TrackerJira:
+		return &JiraFetcher{BaseURL: c.BaseURL, Token: c.Token, Email: c.Email, Client: client}
+	case TrackerLinear:
+		return &LinearFetcher{BaseURL: c.BaseURL, Token: c.Token, Client: client}
+	}
+	return nil
+}
+
+// LoadIssueDetails fetches the comma-separated issue from the configured
+// tracker and formats it for the prompt, capped at tracker.max_chars.
+// Failures only print a warning (unless quiet) since the commit can go on
+// without the details. Results are kept for the rest of the process and in
+// an on-disk cache for tracker.cache_ttl minutes.
+func LoadIssueDetails(ctx context.Context, issue string, quiet bool) string {
+	issue = strings.TrimSpace(issue)
+	if issue == "" {
+		return ""
+	}
+	issueDetailsMu.Lock()
+	defer issueDetailsMu.Unlock()
+	if text, ok := issueDetails[issue]; ok {
+		return text
+	}
+
+	cfg, err := LoadTrackerConfig()
+	if err != nil || cfg.Type == "" {
+		if err != nil && !quiet {
+			color.New(color.FgYellow).Fprintf(os.Stderr, "⚠ Issue details not fetched: %v\n", err)
+		}
+		issueDetails[issue] = ""
+		return ""
+	}
+	fetcher := cfg.Fetcher()
+
+	var parts []string
+	for _, key := range ParseIssueList(issue) {
+		details, err := cfg.cachedFetch(ctx, fetcher, key)
+		if err != nil {
+			if !quiet {
+				color.New(color.FgYellow).Fprintf(os.Stderr, "⚠ Could not fetch issue %s: %v\n", key, err)
+			}
+			continue
+		}
+		if details != nil {
+			parts = append(parts, details.promptText())
+		}
+	}
+
+	text := strings.Join(parts, "\n\n")
+	if cfg.MaxChars > 0 && len([]rune(text)) > cfg.MaxChars {
+		text = strings.TrimSpace(string([]rune(text)[:cfg.MaxChars])) + " …"
+	}
+	issueDetails[issue] = text
+	return text
+}
+
+// cachedIssueDetails returns the text LoadIssueDetails produced for issue,
+// fetching it quietly if it has not been loaded yet.
+func cachedIssueDetails(issue string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), trackerTimeout)
+	defer cancel()
+	return LoadIssueDetails(ctx, issue, true)
+}
+
+func (d *IssueDetails) promptText() string {
+	text := fmt.Sprintf("%s: %s", d.Key, strings.TrimSpace(d.Title))
+	if body := strings.TrimSpace(d.Body); body != "" {
+		text += "\n" + body
+	}
+	return text
+}
+
+func (c TrackerConfig) cachedFetch(ctx context.Context, fetcher IssueFetcher, key string) (*IssueDetails, error) {
+	path := c.cachePath(key)
+	if c.CacheTTL > 0 && path != "" {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < c.CacheTTL {
+			if content, err := os.ReadFile(path); err == nil {
+				var details IssueDetails
+				if json.Unmarshal(content, &details) == nil {
+					return &details, nil
+				}
+			}
+		}
+	}
+
+	details, err := fetcher.FetchIssue(ctx, key)
+	if err != nil || details == nil {
+		return details, err
+	}
+	if c.CacheTTL > 0 && path != "" {
+		// Write failures only cost a refetch.
+		if content, err := json.Marshal(details); err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			_ = os.WriteFile(path, content, 0o600)
+		}
+	}
+	return details, nil
+}
+
+// cachePath names the cache file after the tracker, project and key, never
+// the token.
+func (c TrackerConfig) cachePath(key string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.Type, c.BaseURL, c.Project, key}, "\x00")))
+	return filepath.Join(cacheDir, "opencommit", "issues", hex.EncodeToString(sum[:8])+".json")
+}
+
+// remoteProjectPath returns the "owner/repo" path of the default remote's
+// URL, or "" when there is none.
+func remoteProjectPath() string {
+	git := NewGitService()
+	remoteName, err := git.GetRemoteName()
+	if err != nil {
+		return ""
+	}
+	info, err := git.GetRemoteInfo(remoteName)
+	if err != nil {
+		return ""
+	}
+	return info.Path
+}
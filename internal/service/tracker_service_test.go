@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGitHubFetcher(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("GET /repos/owner/repo/issues/12", reply(200, `{"title":"Crash on empty diff","body":"Steps...","html_url":"https://github.test/owner/repo/issues/12"}`))
+	api.handle("GET /repos/owner/repo/issues/13", reply(404, `{"message":"Not Found"}`))
+
+	fetcher := &GitHubFetcher{BaseURL: srv.URL, Token: "ghp", Project: "owner/repo", Client: srv.Client()}
+	for _, key := range []string{"#12", "12"} {
+		details, err := fetcher.FetchIssue(ctx, key)
+		if err != nil {
+			t.Fatalf("FetchIssue(%s): %v", key, err)
+		}
+		want := IssueDetails{Key: "#12", Title: "Crash on empty diff", Body: "Steps...", URL: "https://github.test/owner/repo/issues/12"}
+		if *details != want {
+			t.Errorf("FetchIssue(%s) = %+v", key, *details)
+		}
+	}
+	if api.auth[0] != "Bearer ghp" {
+		t.Errorf("auth = %q", api.auth[0])
+	}
+	if details, err := fetcher.FetchIssue(ctx, "ABC-1"); details != nil || err != nil {
+		t.Errorf("FetchIssue(ABC-1) = %v, %v; want it skipped", details, err)
+	}
+	if _, err := fetcher.FetchIssue(ctx, "#13"); err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Errorf("error = %v, want the API's message", err)
+	}
+}
+
+func TestGitLabFetcher(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	api.handle("GET /api/v4/projects/group%2Frepo/issues/3", reply(200, `{"title":"Slow push","description":"It hangs","web_url":"https://gitlab.test/group/repo/-/issues/3"}`))
+
+	fetcher := &GitLabFetcher{BaseURL: srv.URL, Token: "glpat", Project: "group/repo", Client: srv.Client()}
+	details, err := fetcher.FetchIssue(context.Background(), "#3")
+	if err != nil {
+		t.Fatalf("FetchIssue: %v", err)
+	}
+	if details.Key != "#3" || details.Title != "Slow push" || details.Body != "It hangs" {
+		t.Errorf("details = %+v", *details)
+	}
+	if api.auth[0] != "glpat" {
+		t.Errorf("PRIVATE-TOKEN = %q", api.auth[0])
+	}
+}
+
+func TestJiraFetcher(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("GET /rest/api/2/issue/PAY-42", func(r *http.Request, _ map[string]interface{}) (int, string) {
+		if r.URL.Query().Get("fields") != "summary,description" {
+			t.Errorf("fields = %q", r.URL.Query().Get("fields"))
+		}
+		return 200, `{"fields":{"summary":"Refund rounding","description":"Off by one cent"}}`
+	})
+
+	cloud := &JiraFetcher{BaseURL: srv.URL, Token: "api-token", Email: "me@example.com", Client: srv.Client()}
+	details, err := cloud.FetchIssue(ctx, "PAY-42")
+	if err != nil {
+		t.Fatalf("FetchIssue: %v", err)
+	}
+	if details.Title != "Refund rounding" || details.Body != "Off by one cent" || details.URL != srv.URL+"/browse/PAY-42" {
+		t.Errorf("details = %+v", *details)
+	}
+	if api.auth[0] != "Basic bWVAZXhhbXBsZS5jb206YXBpLXRva2Vu" {
+		t.Errorf("auth = %q, want basic auth with the email", api.auth[0])
+	}
+
+	server := &JiraFetcher{BaseURL: srv.URL, Token: "pat", Client: srv.Client()}
+	if _, err := server.FetchIssue(ctx, "PAY-42"); err != nil {
+		t.Fatalf("FetchIssue: %v", err)
+	}
+	if api.auth[1] != "Bearer pat" {
+		t.Errorf("auth = %q, want a bearer token", api.auth[1])
+	}
+	if details, err := server.FetchIssue(ctx, "#42"); details != nil || err != nil {
+		t.Errorf("FetchIssue(#42) = %v, %v; want it skipped", details, err)
+	}
+}
+
+func TestLinearFetcher(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t)
+	api.handle("POST /graphql", func(_ *http.Request, body map[string]interface{}) (int, string) {
+		variables, _ := body["variables"].(map[string]interface{})
+		switch variables["id"] {
+		case "ENG-7":
+			return 200, `{"data":{"issue":{"identifier":"ENG-7","title":"Retry uploads","description":"Flaky network","url":"https://linear.test/ENG-7"}}}`
+		case "ENG-8":
+			return 200, `{"data":{"issue":null}}`
+		}
+		return 200, `{"errors":[{"message":"Entity not found"}]}`
+	})
+
+	fetcher := &LinearFetcher{BaseURL: srv.URL, Token: "lin_api", Client: srv.Client()}
+	details, err := fetcher.FetchIssue(ctx, "ENG-7")
+	if err != nil {
+		t.Fatalf("FetchIssue: %v", err)
+	}
+	if details.Key != "ENG-7" || details.Title != "Retry uploads" || details.URL != "https://linear.test/ENG-7" {
+		t.Errorf("details = %+v", *details)
+	}
+	if api.auth[0] != "lin_api" {
+		t.Errorf("auth = %q", api.auth[0])
+	}
+	if _, err := fetcher.FetchIssue(ctx, "ENG-8"); err == nil {
+		t.Error("expected an error for a missing issue")
+	}
+	if _, err := fetcher.FetchIssue(ctx, "ENG-9"); err == nil || !strings.Contains(err.Error(), "Entity not found") {
+		t.Errorf("error = %v, want the GraphQL error", err)
+	}
+}
+
+// resetIssueDetails forgets what LoadIssueDetails memoized in this process.
+func resetIssueDetails() {
+	issueDetailsMu.Lock()
+	issueDetails = map[string]string{}
+	issueDetailsMu.Unlock()
+}
+
+func TestLoadIssueDetailsCacheAndCap(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var hits atomic.Int32
+	api, srv := newFakeAPI(t)
+	api.handle("GET /repos/owner/repo/issues/1", func(*http.Request, map[string]interface{}) (int, string) {
+		hits.Add(1)
+		return 200, `{"title":"First","body":"` + strings.Repeat("a", 100) + `"}`
+	})
+	api.handle("GET /repos/owner/repo/issues/2", func(*http.Request, map[string]interface{}) (int, string) {
+		hits.Add(1)
+		return 200, `{"title":"Second","body":"b"}`
+	})
+
+	for key, value := range map[string]interface{}{
+		"tracker.type":      TrackerGitHub,
+		"tracker.baseurl":   srv.URL,
+		"tracker.token":     "ghp",
+		"tracker.project":   "owner/repo",
+		"tracker.max_chars": 40,
+		"tracker.cache_ttl": 60,
+	} {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		viper.Set("tracker", nil)
+		resetIssueDetails()
+	})
+	resetIssueDetails()
+
+	text := LoadIssueDetails(context.Background(), "#1, #2", true)
+	if got := len([]rune(text)); got != 40+len([]rune(" …")) {
+		t.Errorf("text is %d runes, want it capped at tracker.max_chars: %q", got, text)
+	}
+	if !strings.HasPrefix(text, "#1: First\naaa") || !strings.HasSuffix(text, " …") {
+		t.Errorf("text = %q", text)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("hits = %d, want 2", hits.Load())
+	}
+
+	// A new process (no memo) reads both issues from the disk cache.
+	resetIssueDetails()
+	if again := LoadIssueDetails(context.Background(), "#1, #2", true); again != text {
+		t.Errorf("cached text = %q, want %q", again, text)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("hits = %d after a cached load, want 2", hits.Load())
+	}
+
+	// With the cache disabled every process fetches again.
+	viper.Set("tracker.cache_ttl", 0)
+	resetIssueDetails()
+	LoadIssueDetails(context.Background(), "#2", true)
+	if hits.Load() != 3 {
+		t.Errorf("hits = %d with cache_ttl = 0, want 3", hits.Load())
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type BranchUsecase struct {
+	gitService         *service.GitService
+	aiService          *service.AIService
+	interactionService *service.InteractionService
+}
+
+var (
+	branchUsecaseInstance *BranchUsecase
+	branchUsecaseOnce     sync.Once
+)
+
+func NewBranchUsecase() *BranchUsecase {
+	branchUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		interactionService := service.NewInteractionService()
+
+		branchUsecaseInstance = &BranchUsecase{
+			gitService:         gitService,
+			aiService:          aiService,
+			interactionService: interactionService,
+		}
+	})
+
+	return branchUsecaseInstance
+}
+
+// BranchCommand names a branch after description, or after the uncommitted
+// changes when description is empty, then creates and switches to it.
+func (b *BranchUsecase) BranchCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	description string,
+	issue *string,
+	pattern *string,
+	model *string,
+	maxDiffLines *int,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+) error {
+	if err := b.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := b.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	branchPattern := *pattern
+	if branchPattern == "" {
+		branchPattern = service.DefaultBranchPattern
+		if viper.IsSet("branch.pattern") {
+			branchPattern = viper.GetString("branch.pattern")
+		}
+	}
+	if !strings.Contains(branchPattern, "{slug}") {
+		return service.Errorf(service.ErrCodeInvalidConfig, "branch pattern %q must contain {slug}", branchPattern)
+	}
+
+	var diff string
+	if description == "" {
+		var err error
+		if diff, err = b.gitService.GetUncommittedDiff(); err != nil {
+			return err
+		}
+		if diff == "" {
+			return service.Errorf(
+				service.ErrCodeNoChanges,
+				"no uncommitted changes: describe the task, e.g. opencommit branch \"add login rate limit\"",
+			)
+		}
+		diff, _ = service.OmitIgnoredFiles(diff)
+		if diff, err = service.GuardDiffSecrets(diff); err != nil {
+			return err
+		}
+		if *maxDiffLines > 0 {
+			diff = service.TruncateLargeDiffs(diff, *maxDiffLines)
+		}
+	}
+
+	types := service.LoadLintRules(0).Types
+	var name string
+	for {
+		var suggestion *service.BranchSuggestion
+		var aiErr error
+		suggest := func() {
+			suggestion, aiErr = b.aiService.SuggestBranch(providers, ctx, description, diff, types)
+		}
+		if !*quiet {
+			if spinErr := spinner.New().
+				Title(fmt.Sprintf("AI is naming the branch. (Model: %s)", *model)).
+				Action(suggest).
+				Run(); spinErr != nil {
+				return spinErr
+			}
+		} else {
+			suggest()
+		}
+		if aiErr != nil {
+			return aiErr
+		}
+		name = service.FormatBranchName(branchPattern, suggestion.Type, *issue, suggestion.Slug)
+
+		if *noConfirm || *dryRun {
+			break
+		}
+		action, reviewed, err := b.interactionService.ReviewBranchName(name)
+		if err != nil {
+			return err
+		}
+		if action == service.ActionCancel {
+			color.New(color.FgRed).Println("Branch creation cancelled")
+			return nil
+		}
+		if action == service.ActionConfirm {
+			name = reviewed
+			break
+		}
+	}
+
+	if err := b.gitService.ValidateBranchName(name); err != nil {
+		return err
+	}
+	if b.gitService.BranchExists(name) {
+		return service.Errorf(service.ErrCodeInvalidArgument, "branch %s already exists; switch to it with git switch %s", name, name)
+	}
+
+	if *dryRun {
+		fmt.Println(name)
+		return nil
+	}
+
+	if err := b.gitService.CreateBranch(name); err != nil {
+		return err
+	}
+	if !*quiet {
+		color.New(color.FgGreen).Printf("✔ Switched to a new branch %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type ChangelogUsecase struct {
+	gitService *service.GitService
+	aiService  *service.AIService
+}
+
+var (
+	changelogUsecaseInstance *ChangelogUsecase
+	changelogUsecaseOnce     sync.Once
+)
+
+func NewChangelogUsecase() *ChangelogUsecase {
+	changelogUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+
+		changelogUsecaseInstance = &ChangelogUsecase{
+			gitService: gitService,
+			aiService:  aiService,
+		}
+	})
+
+	return changelogUsecaseInstance
+}
+
+// ChangelogCommand builds the changelog for from..to and prints it, or
+// prepends it to file. providers is only used when polish is set.
+func (c *ChangelogUsecase) ChangelogCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	from *string,
+	to *string,
+	version *string,
+	format *string,
+	file *string,
+	includeAll *bool,
+	polish *bool,
+	model *string,
+	language *string,
+	quiet *bool,
+) error {
+	if err := c.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := c.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	if *format != service.ChangelogFormatMarkdown && *format != service.ChangelogFormatJSON {
+		return service.Errorf(service.ErrCodeInvalidArgument, "invalid format %q (expected %s or %s)", *format, service.ChangelogFormatMarkdown, service.ChangelogFormatJSON)
+	}
+	if *format == service.ChangelogFormatJSON && (*file != "" || *polish) {
+		return service.Errorf(service.ErrCodeInvalidArgument, "--file and --polish only work with markdown")
+	}
+
+	toRev := *to
+	toHash, err := c.gitService.ResolveRevision(toRev)
+	if err != nil {
+		return service.WithCode(service.ErrCodeInvalidArgument, err)
+	}
+	fromRev := *from
+	if fromRev == "" {
+		fromRev = c.gitService.LatestTag(toRev + "^")
+	}
+	revRange := toRev
+	if fromRev != "" {
+		revRange = fromRev + ".." + toRev
+	}
+
+	commits, err := c.gitService.ListCommits(revRange)
+	if err != nil {
+		return err
+	}
+
+	changelog := service.BuildChangelog(commits, *includeAll)
+	changelog.From, changelog.To = fromRev, toRev
+	changelog.Version = *version
+	if changelog.Version == "" {
+		// Keep a Changelog names releases without the tag's v prefix.
+		tag := c.gitService.ExactTag(toRev)
+		if len(tag) > 1 && tag[0] == 'v' && tag[1] >= '0' && tag[1] <= '9' {
+			tag = tag[1:]
+		}
+		changelog.Version = tag
+	}
+	if changelog.Version == "" {
+		changelog.Version = service.ChangelogUnreleased
+	} else if changelog.Date, err = c.gitService.GetCommitDate(toRev); err != nil {
+		return err
+	}
+
+	if *format == service.ChangelogFormatJSON {
+		return service.WriteJSON(os.Stdout, changelog)
+	}
+
+	markdown := changelog.Markdown()
+	if *polish && len(changelog.Sections) > 0 {
+		var polished string
+		var aiErr error
+		run := func() {
+			polished, aiErr = c.aiService.PolishChangelog(providers, ctx, markdown, *language)
+		}
+		// Keep stdout clean when the changelog itself is printed there.
+		if *file != "" && !*quiet {
+			if spinErr := spinner.New().
+				Title(fmt.Sprintf("AI is polishing the release notes. (Model: %s)", *model)).
+				Action(run).
+				Run(); spinErr != nil {
+				return spinErr
+			}
+		} else {
+			run()
+		}
+		if aiErr != nil {
+			return aiErr
+		}
+		markdown = polished
+	}
+
+	if *file == "" {
+		fmt.Print(markdown)
+		return nil
+	}
+
+	existing, err := os.ReadFile(*file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", *file, err)
+	}
+	head, _ := c.gitService.ResolveRevision("HEAD")
+	updated := service.PrependChangelog(string(existing), changelog.Version, markdown, toHash == head)
+	if err := os.WriteFile(*file, []byte(updated), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", *file, err)
+	}
+	if !*quiet {
+		color.New(color.FgGreen).Printf("✔ Updated %s with [%s] (%d commits, %d skipped)\n", *file, changelog.Version, len(commits), changelog.Skipped)
+	}
+	return nil
+}
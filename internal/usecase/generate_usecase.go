@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type GenerateUsecase struct {
+	aiService *service.AIService
+}
+
+var (
+	generateUsecaseInstance *GenerateUsecase
+	generateUsecaseOnce     sync.Once
+)
+
+func NewGenerateUsecase() *GenerateUsecase {
+	generateUsecaseOnce.Do(func() {
+		aiService := service.NewAIService()
+
+		generateUsecaseInstance = &GenerateUsecase{
+			aiService: aiService,
+		}
+	})
+
+	return generateUsecaseInstance
+}
+
+// GenerateCommand writes a commit message for a unified diff read from
+// diffFile ("" or "-" for stdin) and prints only the message. Nothing is
+// staged or committed, and no prompts or spinners are shown. The diff may
+// not come from the current repository, so the branch is only what is passed
+// in, and the learned style is only used with repo (the caller detaches the
+// GitService otherwise).
+func (g *GenerateUsecase) GenerateCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	diffFile *string,
+	files *[]string,
+	branch *string,
+	repo *bool,
+	userContext *string,
+	model *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	maxDiffLines *int,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+) error {
+	diff, err := readDiff(*diffFile)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return service.Errorf(service.ErrCodeNoChanges, "the diff is empty")
+	}
+	if len(*files) > 0 {
+		diff = service.FilterDiffSections(diff, *files)
+		if strings.TrimSpace(diff) == "" {
+			return service.Errorf(service.ErrCodeNoChanges, "the diff has no changes to %s", strings.Join(*files, ", "))
+		}
+	}
+
+	diff, ignored := service.OmitIgnoredFiles(diff)
+	if diff, err = service.GuardDiffSecrets(diff); err != nil {
+		return err
+	}
+
+	quiet, noConfirm, noStream := true, true, true
+	opts := &service.CommitOptions{
+		UserContext:    userContext,
+		Model:          model,
+		NoConfirm:      &noConfirm,
+		Quiet:          &quiet,
+		MaxLength:      maxLength,
+		Language:       language,
+		Issue:          issue,
+		MaxDiffLines:   maxDiffLines,
+		NoStream:       &noStream,
+		Summarize:      summarize,
+		TokenBudget:    tokenBudget,
+		SummaryWorkers: summaryWorkers,
+	}
+	data := &service.PreCommitData{
+		Diff:         diff,
+		RelatedFiles: map[string]string{},
+		Issue:        *issue,
+		Ignored:      ignored,
+		Detached:     true,
+		Branch:       *branch,
+	}
+	if *repo {
+		data.Style = service.LoadStyleProfile()
+	}
+
+	if *summarize {
+		if err := g.aiService.SummarizeIfNeeded(providers, ctx, data, opts); err != nil {
+			return err
+		}
+	} else if *maxDiffLines > 0 {
+		data.Diff = service.TruncateLargeDiffs(data.Diff, *maxDiffLines)
+	}
+
+	message, err := g.aiService.GenerateCommitMessage(providers, ctx, data, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(message)
+	return nil
+}
+
+// readDiff reads a diff from path, or from stdin when path is "" or "-".
+func readDiff(path string) (string, error) {
+	if path != "" && path != "-" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", service.Errorf(service.ErrCodeInvalidArgument, "failed to read diff file: %v", err)
+		}
+		return string(content), nil
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		return "", service.Errorf(
+			service.ErrCodeInvalidArgument,
+			"no diff given: pipe one on stdin (e.g. `git diff | opencommit generate`) or use --diff-file",
+		)
+	}
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read diff from stdin: %v", err)
+	}
+	return string(content), nil
+}
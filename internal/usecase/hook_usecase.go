@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type HookUsecase struct {
+	gitService  *service.GitService
+	aiService   *service.AIService
+	hookService *service.HookService
+}
+
+var (
+	hookUsecaseInstance *HookUsecase
+	hookUsecaseOnce     sync.Once
+)
+
+func NewHookUsecase() *HookUsecase {
+	hookUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		hookService := service.NewHookService()
+
+		hookUsecaseInstance = &HookUsecase{
+			gitService:  gitService,
+			aiService:   aiService,
+			hookService: hookService,
+		}
+	})
+
+	return hookUsecaseInstance
+}
+
+func (h *HookUsecase) verify() error {
+	if err := h.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+	return h.gitService.VerifyGitRepository()
+}
+
+func (h *HookUsecase) Install() error {
+	if err := h.verify(); err != nil {
+		return err
+	}
+	status, err := h.hookService.Install()
+	if err != nil {
+		return err
+	}
+	color.New(color.FgGreen).Printf("✔ Installed prepare-commit-msg hook at %s\n", status.HookPath)
+	if status.Chained {
+		fmt.Println("  The existing hook was kept and runs first.")
+	}
+	return nil
+}
+
+func (h *HookUsecase) Uninstall() error {
+	if err := h.verify(); err != nil {
+		return err
+	}
+	status, err := h.hookService.Uninstall()
+	if err != nil {
+		return err
+	}
+	color.New(color.FgGreen).Printf("✔ Removed prepare-commit-msg hook from %s\n", status.HooksDir)
+	if status.Foreign {
+		fmt.Println("  The previous hook was restored.")
+	}
+	return nil
+}
+
+func (h *HookUsecase) Status() error {
+	if err := h.verify(); err != nil {
+		return err
+	}
+	status, err := h.hookService.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Hooks directory: %s\n", status.HooksDir)
+	switch {
+	case status.Installed:
+		color.New(color.FgGreen).Println("✔ opencommit hook is installed")
+		if status.Chained {
+			fmt.Println("  Chained to the previous hook, which runs first.")
+		}
+	case status.Foreign:
+		color.New(color.FgYellow).Println("⊘ a prepare-commit-msg hook exists that opencommit did not install")
+	default:
+		fmt.Println("✗ opencommit hook is not installed")
+	}
+	return nil
+}
+
+// Run is the prepare-commit-msg entrypoint. It writes an AI-generated
+// message for the staged changes into msgFile, keeping git's comment lines,
+// and does nothing when the message is already supplied (-m/-F, merges,
+// squashes, amends) or the file already has content.
+func (h *HookUsecase) Run(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	msgFile string,
+	source string,
+	opts *service.CommitOptions,
+) error {
+	if service.ShouldSkipHookSource(source) {
+		return nil
+	}
+
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %v", err)
+	}
+	if service.HasCommitMessageContent(string(content)) {
+		return nil
+	}
+
+	files, diff, err := h.gitService.DetectDiffChanges()
+	if err != nil {
+		// Nothing staged: let git report it as usual.
+		return nil
+	}
+	diff, _ = service.OmitIgnoredFiles(diff)
+	if diff, err = service.GuardDiffSecrets(diff); err != nil {
+		return err
+	}
+	if opts.MaxDiffLines != nil && *opts.MaxDiffLines > 0 && (opts.Summarize == nil || !*opts.Summarize) {
+		diff = service.TruncateLargeDiffs(diff, *opts.MaxDiffLines)
+	}
+
+	issue := *opts.Issue
+	if issue == "" {
+		detected, err := h.gitService.DetectIssueFromBranch()
+		if err != nil {
+			return err
+		}
+		issue = detected
+	}
+
+	data := &service.PreCommitData{
+		Files: files,
+		Diff:  diff,
+		Issue: issue,
+	}
+	if err := h.aiService.SummarizeIfNeeded(providers, ctx, data, opts); err != nil {
+		return err
+	}
+
+	relatedFiles := map[string]string{}
+	message, err := h.aiService.AnalyzeChanges(
+		providers,
+		ctx,
+		data.Diff,
+		opts.UserContext,
+		&relatedFiles,
+		opts.MaxLength,
+		opts.Language,
+		&data.Issue,
+	)
+	if err != nil {
+		return err
+	}
+	if message, err = service.ApplyIssueReferences(message, data.Issue); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(msgFile, []byte(message+"\n"+string(content)), 0o644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %v", err)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"os"
 	"sync"
 
 	"github.com/fatih/color"
@@ -51,7 +53,15 @@ func (p *PRUsecase) PRCommand(
 	language *string,
 	userContext *string,
 	draft *bool,
+	base *string,
+	labels *[]string,
+	assignees *[]string,
 	maxDiffLines *int,
+	noStream *bool,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+	output *string,
 ) error {
 	if err := p.gitService.VerifyGitInstallation(); err != nil {
 		return err
@@ -62,25 +72,80 @@ func (p *PRUsecase) PRCommand(
 	}
 
 	opts := &service.CommitOptions{
-		Model:       model,
-		NoConfirm:   noConfirm,
-		Quiet:       quiet,
-		DryRun:      dryRun,
-		ShowDiff:    showDiff,
-		MaxLength:   maxLength,
-		Language:    language,
-		UserContext: userContext,
+		Model:          model,
+		NoConfirm:      noConfirm,
+		Quiet:          quiet,
+		DryRun:         dryRun,
+		ShowDiff:       showDiff,
+		MaxLength:      maxLength,
+		Language:       language,
+		UserContext:    userContext,
+		NoStream:       noStream,
+		Summarize:      summarize,
+		TokenBudget:    tokenBudget,
+		SummaryWorkers: summaryWorkers,
+		Output:         output,
 	}
 
-	data, err := p.gitService.GetDiff()
+	// Look for a pull request to update. Dry runs skip the forge entirely, and
+	// a failed lookup is treated as there being none.
+	var existing *service.PullRequest
+	var noun string
+	if !*dryRun {
+		var err error
+		existing, noun, err = p.gitService.FindOpenPullRequest(ctx)
+		if err != nil {
+			if !*quiet {
+				color.New(color.FgYellow).Fprintf(os.Stderr, "⚠ Could not look up an existing pull request: %v\n", err)
+			}
+			existing = nil
+		}
+	}
+	diffBase := *base
+	if existing != nil {
+		if !*quiet {
+			color.New(color.FgCyan).Printf("Found an open %s for this branch: %s\n", noun, existing.URL)
+			if *draft || len(*labels) > 0 || len(*assignees) > 0 {
+				color.New(color.FgYellow).Println("⚠ --draft, --label and --assignee only apply to new pull requests")
+			}
+		}
+		if existing.Base != "" {
+			diffBase = existing.Base
+		}
+		if !*noConfirm {
+			update, err := p.interactionService.Confirm("Regenerate and update its title and description?")
+			if err != nil {
+				return err
+			}
+			if !update {
+				color.New(color.FgYellow).Printf("The %s was left unchanged\n", noun)
+				return nil
+			}
+		}
+	}
+
+	data, err := p.gitService.GetDiff(diffBase)
 	if err != nil {
 		return err
 	}
+	data.Diff, data.Ignored = service.OmitIgnoredFiles(data.Diff)
+	var secretReport io.Writer = os.Stderr
+	if opts.JSONOutput() {
+		secretReport = nil
+	}
+	if data.Diff, data.Secrets, err = service.GuardDiffSecretsTo(secretReport, data.Diff); err != nil {
+		return err
+	}
 
-	if maxDiffLines != nil && *maxDiffLines > 0 {
+	if *summarize {
+		if err := p.aiService.SummarizeIfNeeded(providers, ctx, data, opts); err != nil {
+			return err
+		}
+	} else if maxDiffLines != nil && *maxDiffLines > 0 {
 		original := data.Diff
 		data.Diff = service.TruncateLargeDiffs(data.Diff, *maxDiffLines)
-		if !*quiet && data.Diff != original {
+		data.Truncated = data.Diff != original
+		if !*quiet && data.Truncated {
 			color.New(color.FgYellow).Printf(
 				"⚠ Diff truncated to %d lines per file to save tokens\n",
 				*maxDiffLines,
@@ -108,14 +173,36 @@ func (p *PRUsecase) PRCommand(
 
 		switch selectedAction {
 		case service.ActionConfirm:
-			if err := p.gitService.CreatePullRequest(
-				finalMessage,
-				opts.Quiet,
-				opts.DryRun,
-				draft,
-			); err != nil {
+			var url string
+			if existing != nil {
+				url, err = p.gitService.UpdatePullRequest(ctx, existing, finalMessage, opts.Quiet, opts.DryRun)
+			} else {
+				url, err = p.gitService.CreatePullRequest(
+					ctx,
+					finalMessage,
+					opts.Quiet,
+					opts.DryRun,
+					draft,
+					base,
+					labels,
+					assignees,
+				)
+			}
+			if err != nil {
 				return err
 			}
+			if opts.JSONOutput() {
+				report := service.NewReport("pr", finalMessage, data, opts)
+				report.Action = service.ReportActionPRCreated
+				if existing != nil {
+					report.Action = service.ReportActionPRUpdated
+				}
+				if *dryRun {
+					report.Action = service.ReportActionDryRun
+				}
+				report.PullRequestURL = url
+				return service.WriteJSON(os.Stdout, report)
+			}
 			return nil
 		case service.ActionRegenerate:
 			continue
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type PromptUsecase struct {
+	gitService *service.GitService
+	aiService  *service.AIService
+}
+
+var (
+	promptUsecaseInstance *PromptUsecase
+	promptUsecaseOnce     sync.Once
+)
+
+func NewPromptUsecase() *PromptUsecase {
+	promptUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+
+		promptUsecaseInstance = &PromptUsecase{
+			gitService: gitService,
+			aiService:  aiService,
+		}
+	})
+
+	return promptUsecaseInstance
+}
+
+// Show prints the named prompts rendered for the staged changes, or the raw
+// templates when raw is set. With no names, the system and user prompts used
+// for a normal commit are shown.
+func (p *PromptUsecase) Show(
+	names []string,
+	raw *bool,
+	userContext *string,
+	maxLength *int,
+	language *string,
+	issue *string,
+	maxDiffLines *int,
+) error {
+	if len(names) == 0 {
+		names = []string{service.PromptSystem, service.PromptUser}
+	}
+
+	var data *service.PromptData
+	if !*raw {
+		if err := p.gitService.VerifyGitInstallation(); err != nil {
+			return err
+		}
+		if err := p.gitService.VerifyGitRepository(); err != nil {
+			return err
+		}
+
+		stageAll, autoSelect, quiet, summarize := false, false, true, false
+		preCommitData, err := p.gitService.DetectAndPrepareChanges(&service.CommitOptions{
+			StageAll:     &stageAll,
+			AutoSelect:   &autoSelect,
+			Quiet:        &quiet,
+			Issue:        issue,
+			MaxDiffLines: maxDiffLines,
+			Summarize:    &summarize,
+		})
+		if err != nil {
+			color.New(color.FgYellow).Fprintf(os.Stderr, "⚠ %v; rendering with an empty diff\n", err)
+			preCommitData = &service.PreCommitData{Issue: *issue}
+		}
+		data = p.aiService.PromptDataFor(preCommitData, userContext, maxLength, language)
+	}
+
+	for i, name := range names {
+		var text, source string
+		var err error
+		if *raw {
+			text, source, err = service.LoadPromptTemplate(name)
+		} else {
+			_, source, err = service.LoadPromptTemplate(name)
+			if err == nil {
+				text, err = service.RenderRequestPrompt(name, data)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		color.New(color.Bold, color.Underline).Printf("%s prompt (%s)\n", name, source)
+		fmt.Println(text)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type ReleaseUsecase struct {
+	gitService         *service.GitService
+	aiService          *service.AIService
+	interactionService *service.InteractionService
+}
+
+var (
+	releaseUsecaseInstance *ReleaseUsecase
+	releaseUsecaseOnce     sync.Once
+)
+
+func NewReleaseUsecase() *ReleaseUsecase {
+	releaseUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		interactionService := service.NewInteractionService()
+
+		releaseUsecaseInstance = &ReleaseUsecase{
+			gitService:         gitService,
+			aiService:          aiService,
+			interactionService: interactionService,
+		}
+	})
+
+	return releaseUsecaseInstance
+}
+
+// ReleaseCommand computes the next semantic version from the Conventional
+// Commits since the last release tag and creates an annotated tag for it at
+// HEAD. providers is only used when noAI is not set.
+func (r *ReleaseUsecase) ReleaseCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	bump *string,
+	channel *string,
+	tagPrefix *string,
+	push *bool,
+	noAI *bool,
+	model *string,
+	language *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+) error {
+	if err := r.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := r.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	// The last stable release reachable from HEAD is the base version.
+	merged, err := r.gitService.ListMergedTags()
+	if err != nil {
+		return err
+	}
+	var lastTag, lastPrefix string
+	var current service.SemVer
+	for _, tag := range merged {
+		prefix, version, ok := service.ParseSemVerTag(tag)
+		if !ok || version.Pre != "" {
+			continue
+		}
+		if lastTag == "" || version.Compare(current) > 0 {
+			lastTag, lastPrefix, current = tag, prefix, version
+		}
+	}
+
+	revRange := "HEAD"
+	if lastTag != "" {
+		revRange = lastTag + "..HEAD"
+	}
+	commits, err := r.gitService.ListCommits(revRange)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return service.Errorf(service.ErrCodeNoChanges, "no commits since %s", lastTag)
+	}
+
+	increment := service.ReleaseBump(commits)
+	if *bump != "" {
+		var ok bool
+		if increment, ok = service.BumpNames[*bump]; !ok {
+			return service.Errorf(service.ErrCodeInvalidArgument, "invalid bump %q (expected major, minor or patch)", *bump)
+		}
+	}
+	if increment == service.BumpNone {
+		return service.Errorf(
+			service.ErrCodeNoChanges,
+			"no feat, fix or breaking changes since %s; pass --bump to release anyway",
+			orInitial(lastTag),
+		)
+	}
+
+	allTags, err := r.gitService.ListTags()
+	if err != nil {
+		return err
+	}
+	var existing []service.SemVer
+	for _, tag := range allTags {
+		if _, version, ok := service.ParseSemVerTag(tag); ok {
+			existing = append(existing, version)
+		}
+	}
+	next := service.NextVersion(current, increment, *channel, existing)
+
+	prefix := *tagPrefix
+	switch {
+	case prefix != "":
+	case viper.IsSet("release.tag_prefix"):
+		prefix = viper.GetString("release.tag_prefix")
+	case lastTag != "":
+		prefix = lastPrefix
+	default:
+		prefix = service.DefaultTagPrefix
+	}
+	tagName := prefix + next.String()
+	for _, tag := range allTags {
+		if tag == tagName {
+			return service.Errorf(service.ErrCodeInvalidArgument, "tag %s already exists", tagName)
+		}
+	}
+
+	changelog := service.BuildChangelog(commits, false)
+	changelog.Version = next.String()
+	markdown := changelog.Markdown()
+
+	body, err := r.tagMessageBody(providers, ctx, markdown, noAI, model, language, quiet)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Release %s\n\n%s", tagName, body)
+
+	if !*quiet || *dryRun {
+		if *dryRun {
+			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
+		}
+		color.New(color.Underline).Println("Release plan:")
+		fmt.Printf("  Current version: %s (%d commits since)\n", orInitial(lastTag), len(commits))
+		fmt.Printf("  Bump:            %s\n", increment)
+		color.New(color.Bold).Printf("  Next version:    %s\n", tagName)
+		if *push {
+			fmt.Println("  Push:            yes")
+		}
+		fmt.Printf("\nTag message:\n\n%s\n\n", indent(message, "  "))
+	}
+	if *dryRun {
+		return nil
+	}
+
+	if !*noConfirm {
+		confirmed, err := r.interactionService.Confirm(fmt.Sprintf("Create tag %s?", tagName))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			color.New(color.FgRed).Println("Release cancelled")
+			return nil
+		}
+	}
+
+	if err := r.gitService.CreateAnnotatedTag(tagName, message); err != nil {
+		return err
+	}
+	if !*quiet {
+		color.New(color.FgGreen).Printf("✔ Created tag %s\n", tagName)
+	}
+
+	if *push {
+		remoteName, err := r.gitService.GetRemoteName()
+		if err != nil {
+			return err
+		}
+		if err := r.gitService.PushTag(remoteName, tagName, quiet); err != nil {
+			return err
+		}
+		if !*quiet {
+			color.New(color.FgGreen).Printf("✔ Pushed %s to %s\n", tagName, remoteName)
+		}
+	}
+	return nil
+}
+
+// tagMessageBody summarizes the release with AI, or uses the changelog
+// entries as they are when noAI is set.
+func (r *ReleaseUsecase) tagMessageBody(
+	providers []service.ProviderConfig,
+	ctx context.Context,
+	markdown string,
+	noAI *bool,
+	model *string,
+	language *string,
+	quiet *bool,
+) (string, error) {
+	if *noAI {
+		// Drop the "## [version]" heading; the tag title names the version.
+		_, entries, _ := strings.Cut(markdown, "\n")
+		return strings.TrimSpace(entries), nil
+	}
+
+	var body string
+	var aiErr error
+	run := func() {
+		body, aiErr = r.aiService.SummarizeRelease(providers, ctx, markdown, *language)
+	}
+	if !*quiet {
+		if spinErr := spinner.New().
+			Title(fmt.Sprintf("AI is summarizing the release. (Model: %s)", *model)).
+			Action(run).
+			Run(); spinErr != nil {
+			return "", spinErr
+		}
+	} else {
+		run()
+	}
+	return body, aiErr
+}
+
+func orInitial(tag string) string {
+	if tag == "" {
+		return "none (first release)"
+	}
+	return tag
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type RewordUsecase struct {
+	gitService         *service.GitService
+	aiService          *service.AIService
+	interactionService *service.InteractionService
+}
+
+var (
+	rewordUsecaseInstance *RewordUsecase
+	rewordUsecaseOnce     sync.Once
+)
+
+func NewRewordUsecase() *RewordUsecase {
+	rewordUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		interactionService := service.NewInteractionService()
+
+		rewordUsecaseInstance = &RewordUsecase{
+			gitService:         gitService,
+			aiService:          aiService,
+			interactionService: interactionService,
+		}
+	})
+
+	return rewordUsecaseInstance
+}
+
+// RewordCommand generates a new message for every commit in revRange from
+// the commit's own diff and, once reviewed, rewrites the current branch with
+// them. Trees, authors and dates are kept.
+func (r *RewordUsecase) RewordCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	revRange string,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	force *bool,
+	maxLength *int,
+	language *string,
+	maxDiffLines *int,
+) error {
+	if err := r.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := r.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	commits, err := r.commitsToReword(revRange, *force)
+	if err != nil {
+		return err
+	}
+
+	opts := &service.CommitOptions{
+		UserContext:  userContext,
+		Model:        model,
+		NoConfirm:    noConfirm,
+		Quiet:        quiet,
+		DryRun:       dryRun,
+		MaxLength:    maxLength,
+		Language:     language,
+		MaxDiffLines: maxDiffLines,
+	}
+
+	var messages []string
+	for {
+		if messages == nil {
+			messages, err = r.generateMessages(providers, ctx, commits, opts)
+			if err != nil {
+				return err
+			}
+		}
+
+		action, reviewed, err := r.interactionService.ReviewRewordPlan(commits, messages, opts)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case service.ActionConfirm:
+			return r.rewrite(commits, messages, opts)
+		case service.ActionEdit:
+			messages = reviewed
+			continue
+		case service.ActionRegenerate:
+			messages = nil
+			continue
+		case service.ActionCancel:
+			color.New(color.FgRed).Println("Reword cancelled")
+			return nil
+		}
+	}
+}
+
+// commitsToReword lists the commits in revRange, which must end at HEAD. A
+// single revision means <rev>..HEAD. Merge commits are refused, and so are
+// commits already on the upstream branch (or, without one, on any remote
+// branch) unless force is set.
+func (r *RewordUsecase) commitsToReword(revRange string, force bool) ([]service.CommitInfo, error) {
+	base, tip, isRange := strings.Cut(revRange, "..")
+	if !isRange {
+		tip = "HEAD"
+	}
+	if strings.HasPrefix(tip, ".") {
+		return nil, service.Errorf(service.ErrCodeInvalidArgument, "symmetric ranges (A...B) are not supported")
+	}
+	if tip == "" {
+		tip = "HEAD"
+	}
+
+	head, err := r.gitService.ResolveRevision("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	tipHash, err := r.gitService.ResolveRevision(tip)
+	if err != nil {
+		return nil, service.WithCode(service.ErrCodeInvalidArgument, err)
+	}
+	if tipHash != head {
+		return nil, service.Errorf(service.ErrCodeInvalidArgument, "the range must end at HEAD (%s is not the current commit)", tip)
+	}
+	if base == "" {
+		return nil, service.Errorf(service.ErrCodeInvalidArgument, "the range needs a base, e.g. main..HEAD or HEAD~3")
+	}
+
+	commits, err := r.gitService.ListCommits(base + ".." + head)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, service.Errorf(service.ErrCodeNoChanges, "no commits in %s", revRange)
+	}
+
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			return nil, service.Errorf(
+				service.ErrCodeInvalidArgument,
+				"%s is a merge commit; reword only handles linear history",
+				service.ShortHash(commit.Hash),
+			)
+		}
+	}
+
+	if force {
+		return commits, nil
+	}
+	upstream := r.gitService.GetUpstream()
+	published := 0
+	for _, commit := range commits {
+		if upstream != "" {
+			if r.gitService.IsAncestor(commit.Hash, upstream) {
+				published++
+			}
+		} else if r.gitService.OnRemoteBranch(commit.Hash) {
+			published++
+		}
+	}
+	if published > 0 {
+		where := upstream
+		if where == "" {
+			where = "a remote branch"
+		}
+		return nil, service.Errorf(
+			service.ErrCodeInvalidArgument,
+			"%d commit(s) in the range are already on %s; rewording them rewrites published history (use --force to do it anyway)",
+			published,
+			where,
+		)
+	}
+	return commits, nil
+}
+
+// generateMessages writes a new message for each commit from its diff, with
+// the current message as a hint.
+func (r *RewordUsecase) generateMessages(
+	providers []service.ProviderConfig,
+	ctx context.Context,
+	commits []service.CommitInfo,
+	opts *service.CommitOptions,
+) ([]string, error) {
+	messages := make([]string, len(commits))
+	var genErr error
+	run := func() {
+		quiet, noStream := true, true
+		for i, commit := range commits {
+			diff, err := r.gitService.GetCommitDiff(commit.Hash)
+			if err != nil {
+				genErr = err
+				return
+			}
+			diff, ignored := service.OmitIgnoredFiles(diff)
+			if diff, err = service.GuardDiffSecrets(diff); err != nil {
+				genErr = err
+				return
+			}
+			if *opts.MaxDiffLines > 0 {
+				diff = service.TruncateLargeDiffs(diff, *opts.MaxDiffLines)
+			}
+
+			commitContext := fmt.Sprintf(
+				"The commit currently has this message, which may be uninformative; keep any issue references or footers it has:\n%s",
+				commit.Message,
+			)
+			if *opts.UserContext != "" {
+				commitContext = *opts.UserContext + "\n\n" + commitContext
+			}
+			commitOpts := *opts
+			commitOpts.UserContext = &commitContext
+			commitOpts.Quiet = &quiet
+			commitOpts.NoStream = &noStream
+
+			data := &service.PreCommitData{
+				Diff:         diff,
+				RelatedFiles: map[string]string{},
+				Ignored:      ignored,
+			}
+			messages[i], err = r.aiService.GenerateCommitMessage(providers, ctx, data, &commitOpts)
+			if err != nil {
+				genErr = fmt.Errorf("%s: %w", service.ShortHash(commit.Hash), err)
+				return
+			}
+		}
+	}
+
+	if !*opts.Quiet {
+		if spinErr := spinner.New().
+			Title(fmt.Sprintf("AI is rewording %d commits. (Model: %s)", len(commits), *opts.Model)).
+			Action(run).
+			Run(); spinErr != nil {
+			return nil, spinErr
+		}
+	} else {
+		run()
+	}
+	if genErr != nil {
+		color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", genErr)
+		return nil, genErr
+	}
+	return messages, nil
+}
+
+func (r *RewordUsecase) rewrite(commits []service.CommitInfo, messages []string, opts *service.CommitOptions) error {
+	if *opts.DryRun {
+		if !*opts.Quiet {
+			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
+			r.interactionService.DisplayRewordPlan(commits, messages)
+		}
+		return nil
+	}
+
+	oldHead := commits[len(commits)-1].Hash
+	newHead, err := r.gitService.RewriteCommitMessages(commits, messages)
+	if err != nil {
+		return err
+	}
+
+	if !*opts.Quiet {
+		if newHead == oldHead {
+			color.New(color.FgYellow).Println("No messages changed")
+			return nil
+		}
+		color.New(color.FgGreen).Printf("✔ Reworded %d commits!\n", len(commits))
+		fmt.Printf("To undo, run: git reset --keep %s\n", oldHead)
+	}
+	return nil
+}
@@ -56,6 +56,11 @@ func (r *RootUsecase) RootCommand(
 	issue *string,
 	noVerify *bool,
 	maxDiffLines *int,
+	noStream *bool,
+	summarize *bool,
+	tokenBudget *int,
+	summaryWorkers *int,
+	output *string,
 ) error {
 	// Perform git verifications
 	if err := r.gitService.VerifyGitInstallation(); err != nil {
@@ -68,20 +73,25 @@ func (r *RootUsecase) RootCommand(
 
 	// Prepare commit options
 	opts := &service.CommitOptions{
-		StageAll:     stageAll,
-		AutoSelect:   autoSelect,
-		UserContext:  userContext,
-		Model:        model,
-		NoConfirm:    noConfirm,
-		Quiet:        quiet,
-		Push:         push,
-		DryRun:       dryRun,
-		ShowDiff:     showDiff,
-		MaxLength:    maxLength,
-		Language:     language,
-		Issue:        issue,
-		NoVerify:     noVerify,
-		MaxDiffLines: maxDiffLines,
+		StageAll:       stageAll,
+		AutoSelect:     autoSelect,
+		UserContext:    userContext,
+		Model:          model,
+		NoConfirm:      noConfirm,
+		Quiet:          quiet,
+		Push:           push,
+		DryRun:         dryRun,
+		ShowDiff:       showDiff,
+		MaxLength:      maxLength,
+		Language:       language,
+		Issue:          issue,
+		NoVerify:       noVerify,
+		MaxDiffLines:   maxDiffLines,
+		NoStream:       noStream,
+		Summarize:      summarize,
+		TokenBudget:    tokenBudget,
+		SummaryWorkers: summaryWorkers,
+		Output:         output,
 	}
 
 	// Detect and prepare changes
@@ -89,6 +99,12 @@ func (r *RootUsecase) RootCommand(
 	if err != nil {
 		return err
 	}
+	// Fetch tracker details before the spinner starts so warnings stay readable.
+	service.LoadIssueDetails(ctx, data.Issue, *opts.Quiet || opts.JSONOutput())
+
+	if err := r.aiService.SummarizeIfNeeded(providers, ctx, data, opts); err != nil {
+		return err
+	}
 
 	// Display detected files (skip this in auto mode since AI will select a subset later)
 	if !*opts.AutoSelect {
@@ -111,13 +127,13 @@ func (r *RootUsecase) RootCommand(
 		data = autoResult.Data
 		initialCommitMessage = autoResult.CommitMessage
 
-		// In auto mode, stage only the selected files for the commit
+		// In auto mode, stage only the selected files and hunks for the commit
 		if err := r.gitService.ResetStaged(); err != nil {
-			return fmt.Errorf("failed to reset staged files: %v", err)
+			return service.WithCode(service.ErrCodeCommitFailed, fmt.Errorf("failed to reset staged files: %w", err))
 		}
 
-		if err := r.gitService.StageFiles(data.Files); err != nil {
-			return fmt.Errorf("failed to stage selected files: %v", err)
+		if err := r.gitService.StageSelection(data.Files, data.HunkDiff()); err != nil {
+			return service.WithCode(service.ErrCodeCommitFailed, fmt.Errorf("failed to stage selected files: %w", err))
 		}
 	}
 
@@ -142,6 +158,9 @@ func (r *RootUsecase) RootCommand(
 			if err := r.gitService.ConfirmAction(finalMessage, opts.Quiet, opts.Push, opts.DryRun, opts.NoVerify); err != nil {
 				return err
 			}
+			if opts.JSONOutput() {
+				return r.writeReport(finalMessage, data, opts)
+			}
 			return nil
 		case service.ActionRegenerate:
 			message = ""
@@ -156,6 +175,27 @@ func (r *RootUsecase) RootCommand(
 	}
 }
 
+// writeReport prints the --output json document for a confirmed commit.
+func (r *RootUsecase) writeReport(message string, data *service.PreCommitData, opts *service.CommitOptions) error {
+	report := service.NewReport("commit", message, data, opts)
+	switch {
+	case *opts.DryRun:
+		report.Action = service.ReportActionDryRun
+	case *opts.Push:
+		report.Action = service.ReportActionPushed
+	default:
+		report.Action = service.ReportActionCommitted
+	}
+	if !*opts.DryRun {
+		hash, err := r.gitService.GetHeadCommit()
+		if err != nil {
+			return err
+		}
+		report.Commit = hash
+	}
+	return service.WriteJSON(os.Stdout, report)
+}
+
 // AutoFlowResult contains both selected files and generated commit message
 type AutoFlowResult struct {
 	Data          *service.PreCommitData
@@ -169,6 +209,7 @@ func (r *RootUsecase) handleAutoFlow(
 	data *service.PreCommitData,
 	opts *service.CommitOptions,
 ) (*AutoFlowResult, error) {
+	hunkDiff := data.HunkDiff()
 	selectFilesAndGenerateCommit := func() ([]string, string, error) {
 		selectOpts := &service.SelectFilesAndGenerateCommitOptions{
 			UserContext:  opts.UserContext,
@@ -176,6 +217,7 @@ func (r *RootUsecase) handleAutoFlow(
 			MaxLength:    opts.MaxLength,
 			Language:     opts.Language,
 			Issue:        &data.Issue,
+			HunkDiff:     &hunkDiff,
 		}
 		selectedFiles, commitMessage, err := r.aiService.SelectFilesAndGenerateCommit(
 			providers,
@@ -187,7 +229,7 @@ func (r *RootUsecase) handleAutoFlow(
 			return nil, "", err
 		}
 		if commitMessage == "" {
-			return nil, "", fmt.Errorf("AI returned an empty commit message")
+			return nil, "", service.Errorf(service.ErrCodeEmptyMessage, "AI returned an empty commit message")
 		}
 		return selectedFiles, commitMessage, nil
 	}
@@ -209,20 +251,28 @@ func (r *RootUsecase) handleAutoFlow(
 		selectedFiles, commitMessage, aiErr = selectFilesAndGenerateCommit()
 	}
 	if aiErr != nil {
-		color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", aiErr)
+		if !opts.JSONOutput() {
+			color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", aiErr)
+		}
 		return nil, aiErr
 	}
+	// The model never saw the ignored files; commit them with the selection.
+	selectedFiles = service.AttachIgnoredFiles(selectedFiles, data.Ignored)
 
-	action, confirmedFiles, err := r.interactionService.ConfirmAutoSelectedFiles(selectedFiles)
-	if err != nil {
-		return nil, err
+	action, confirmedFiles := service.ActionConfirm, selectedFiles
+	if !*opts.NoConfirm {
+		var err error
+		action, confirmedFiles, err = r.interactionService.ConfirmAutoSelectedFiles(selectedFiles, hunkDiff)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	switch action {
 	case service.ActionCancel:
-		return nil, fmt.Errorf("operation cancelled")
+		return nil, service.Errorf(service.ErrCodeCancelled, "operation cancelled")
 	case service.ActionEdit:
-		editedFiles, err := r.interactionService.EditFileList(selectedFiles)
+		editedFiles, err := r.interactionService.EditFileList(selectedFiles, hunkDiff)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type SplitUsecase struct {
+	gitService         *service.GitService
+	aiService          *service.AIService
+	interactionService *service.InteractionService
+}
+
+var (
+	splitUsecaseInstance *SplitUsecase
+	splitUsecaseOnce     sync.Once
+)
+
+func NewSplitUsecase() *SplitUsecase {
+	splitUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		interactionService := service.NewInteractionService()
+
+		splitUsecaseInstance = &SplitUsecase{
+			gitService:         gitService,
+			aiService:          aiService,
+			interactionService: interactionService,
+		}
+	})
+
+	return splitUsecaseInstance
+}
+
+func (s *SplitUsecase) SplitCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	maxLength *int,
+	language *string,
+	issue *string,
+	noVerify *bool,
+	maxDiffLines *int,
+) error {
+	if err := s.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := s.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	opts := &service.CommitOptions{
+		UserContext:  userContext,
+		Model:        model,
+		NoConfirm:    noConfirm,
+		Quiet:        quiet,
+		DryRun:       dryRun,
+		MaxLength:    maxLength,
+		Language:     language,
+		Issue:        issue,
+		NoVerify:     noVerify,
+		MaxDiffLines: maxDiffLines,
+	}
+
+	// Each planned commit is staged from its file list, so whatever is
+	// staged now would be lost; settle that before asking the AI.
+	if !*dryRun && s.gitService.HasStagedChanges() {
+		proceed, err := s.confirmUnstage(opts)
+		if err != nil || !proceed {
+			return err
+		}
+	}
+
+	files, err := s.gitService.GetAllChanges()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no changes found in working directory")
+	}
+
+	diff, err := s.gitService.GetDiffWithUntracked()
+	if err != nil {
+		return err
+	}
+	diff, ignored := service.OmitIgnoredFiles(diff)
+	if diff, err = service.GuardDiffSecrets(diff); err != nil {
+		return err
+	}
+	if *maxDiffLines > 0 {
+		diff = service.TruncateLargeDiffs(diff, *maxDiffLines)
+	}
+
+	issueRef := *issue
+	if issueRef == "" {
+		detected, err := s.gitService.DetectIssueFromBranch()
+		if err != nil {
+			return err
+		}
+		if detected != "" {
+			issueRef = detected
+			if !*quiet {
+				color.New(color.FgCyan).Printf("Auto-detected issue: %s\n", detected)
+			}
+		}
+	}
+	service.LoadIssueDetails(ctx, issueRef, *quiet)
+
+	planOpts := &service.SelectFilesAndGenerateCommitOptions{
+		UserContext: opts.UserContext,
+		MaxLength:   opts.MaxLength,
+		Language:    opts.Language,
+		Issue:       &issueRef,
+	}
+
+	var plan []service.CommitPlanEntry
+	var unplanned []string
+	for {
+		if plan == nil {
+			plan, unplanned, err = s.planCommits(providers, ctx, diff, files, planOpts, opts)
+			if err != nil {
+				return err
+			}
+			plan, unplanned = service.AttachIgnoredToPlan(plan, unplanned, ignored)
+		}
+
+		action, reviewed, err := s.interactionService.ReviewCommitPlan(plan, unplanned, files, opts)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case service.ActionConfirm:
+			return s.executePlan(plan, opts)
+		case service.ActionEdit:
+			plan, unplanned = service.NormalizeCommitPlan(reviewed, files)
+			continue
+		case service.ActionRegenerate:
+			plan = nil
+			continue
+		case service.ActionCancel:
+			color.New(color.FgRed).Println("Split cancelled")
+			return nil
+		}
+	}
+}
+
+func (s *SplitUsecase) planCommits(
+	providers []service.ProviderConfig,
+	ctx context.Context,
+	diff string,
+	files []string,
+	planOpts *service.SelectFilesAndGenerateCommitOptions,
+	opts *service.CommitOptions,
+) ([]service.CommitPlanEntry, []string, error) {
+	var plan []service.CommitPlanEntry
+	var unplanned []string
+	var aiErr error
+	run := func() {
+		plan, unplanned, aiErr = s.aiService.PlanCommits(providers, ctx, diff, files, planOpts)
+	}
+
+	if !*opts.Quiet {
+		if spinErr := spinner.New().
+			Title(fmt.Sprintf("AI is planning your commits. (Model: %s)", *opts.Model)).
+			Action(run).
+			Run(); spinErr != nil {
+			return nil, nil, spinErr
+		}
+	} else {
+		run()
+	}
+	if aiErr != nil {
+		color.New(color.FgRed).Fprintf(os.Stderr, "AI request failed: %v\n", aiErr)
+		return nil, nil, aiErr
+	}
+	return plan, unplanned, nil
+}
+
+// confirmUnstage asks before a split discards the current index. With
+// --yes it refuses instead, since partial hunks (git add -p) cannot be
+// rebuilt from the plan.
+func (s *SplitUsecase) confirmUnstage(opts *service.CommitOptions) (bool, error) {
+	if *opts.NoConfirm {
+		return false, service.Errorf(
+			service.ErrCodeInvalidArgument,
+			"the index has staged changes that split would unstage; commit or stash them (git stash) first, or unstage them with git reset",
+		)
+	}
+
+	out := color.New(color.FgYellow)
+	out.Fprintln(os.Stderr, "⚠ The index has staged changes. Split stages each commit from the plan, so they will be unstaged")
+	out.Fprintln(os.Stderr, "  (partially staged files are staged whole). To keep them, cancel and run: git stash")
+	proceed, err := s.interactionService.Confirm("Unstage them and continue?")
+	if err != nil {
+		return false, err
+	}
+	if !proceed {
+		color.New(color.FgRed).Println("Split cancelled")
+	}
+	return proceed, nil
+}
+
+// executePlan stages and commits each planned entry in order. On failure the
+// index is reset so the remaining changes are left unstaged in the working
+// tree, and the user is told how to undo the commits already created.
+func (s *SplitUsecase) executePlan(plan []service.CommitPlanEntry, opts *service.CommitOptions) error {
+	if *opts.DryRun {
+		if !*opts.Quiet {
+			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
+			s.interactionService.DisplayCommitPlan(plan, nil)
+		}
+		return nil
+	}
+
+	originalHead, err := s.gitService.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+
+	if err := s.gitService.ResetStaged(); err != nil {
+		return err
+	}
+
+	for idx, entry := range plan {
+		err := s.gitService.StageFiles(entry.Files)
+		if err == nil {
+			err = s.gitService.CommitChangesWithOptions(entry.Message, opts.Quiet, opts.NoVerify)
+		}
+		if err != nil {
+			s.reportPartialSplit(idx, len(plan), originalHead)
+			return service.WithCode(service.ErrCodeCommitFailed, fmt.Errorf("commit %d of %d failed: %w", idx+1, len(plan), err))
+		}
+		if !*opts.Quiet {
+			color.New(color.FgGreen).Printf("✔ Committed %d of %d\n", idx+1, len(plan))
+		}
+	}
+
+	if !*opts.Quiet {
+		color.New(color.FgGreen).Printf("✔ Successfully created %d commits!\n", len(plan))
+	}
+	return nil
+}
+
+func (s *SplitUsecase) reportPartialSplit(done int, total int, originalHead string) {
+	resetErr := s.gitService.ResetStaged()
+
+	out := color.New(color.FgYellow)
+	out.Fprintf(os.Stderr, "Split stopped after %d of %d commits.\n", done, total)
+	if resetErr != nil {
+		out.Fprintf(os.Stderr, "Failed to unstage the partial commit: %v\n", resetErr)
+	} else {
+		out.Fprintln(os.Stderr, "The remaining changes are unstaged in your working tree.")
+	}
+	if done > 0 && originalHead != "" {
+		out.Fprintf(os.Stderr, "To undo the commits already created, run: git reset --soft %s\n", originalHead)
+	}
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"github.com/lorne-luo/open-commit/internal/service"
+)
+
+type SquashUsecase struct {
+	gitService         *service.GitService
+	aiService          *service.AIService
+	interactionService *service.InteractionService
+}
+
+var (
+	squashUsecaseInstance *SquashUsecase
+	squashUsecaseOnce     sync.Once
+)
+
+func NewSquashUsecase() *SquashUsecase {
+	squashUsecaseOnce.Do(func() {
+		gitService := service.NewGitService()
+		aiService := service.NewAIService()
+		interactionService := service.NewInteractionService()
+
+		squashUsecaseInstance = &SquashUsecase{
+			gitService:         gitService,
+			aiService:          aiService,
+			interactionService: interactionService,
+		}
+	})
+
+	return squashUsecaseInstance
+}
+
+// SquashMessageCommand writes one Conventional Commit message for all commits
+// between base and HEAD, from their messages and the net diff, keeping issue
+// references and breaking changes. It prints the message, or with commit set
+// soft-resets the branch to the merge base and commits it.
+func (s *SquashUsecase) SquashMessageCommand(
+	ctx context.Context,
+	providers []service.ProviderConfig,
+	base *string,
+	commit *bool,
+	userContext *string,
+	model *string,
+	noConfirm *bool,
+	quiet *bool,
+	dryRun *bool,
+	noVerify *bool,
+	maxLength *int,
+	language *string,
+	maxDiffLines *int,
+) error {
+	if err := s.gitService.VerifyGitInstallation(); err != nil {
+		return err
+	}
+
+	if err := s.gitService.VerifyGitRepository(); err != nil {
+		return err
+	}
+
+	baseRef := *base
+	if baseRef == "" {
+		detected, err := s.gitService.DefaultBaseBranch()
+		if err != nil {
+			return err
+		}
+		baseRef = detected
+	}
+	mergeBase, err := s.gitService.MergeBase(baseRef, "HEAD")
+	if err != nil {
+		return err
+	}
+	head, err := s.gitService.ResolveRevision("HEAD")
+	if err != nil {
+		return err
+	}
+
+	history, err := s.gitService.ListCommits(mergeBase + "..HEAD")
+	if err != nil {
+		return err
+	}
+	var commits []service.CommitInfo
+	for _, c := range history {
+		if len(c.Parents) <= 1 {
+			commits = append(commits, c)
+		}
+	}
+	if len(commits) == 0 {
+		return service.Errorf(service.ErrCodeNoChanges, "no commits between %s and HEAD", baseRef)
+	}
+
+	diff, err := s.gitService.GetDiffBetween(mergeBase, "HEAD")
+	if err != nil {
+		return err
+	}
+	diff, ignored := service.OmitIgnoredFiles(diff)
+	if diff, err = service.GuardDiffSecrets(diff); err != nil {
+		return err
+	}
+	if *maxDiffLines > 0 {
+		diff = service.TruncateLargeDiffs(diff, *maxDiffLines)
+	}
+
+	commitList := service.SquashContext(commits)
+	trailers := service.CollectSquashTrailers(commits)
+
+	data := &service.PreCommitData{
+		Diff:         diff,
+		RelatedFiles: map[string]string{},
+		Ignored:      ignored,
+	}
+
+	if !*commit {
+		// Print only the message, so it can be piped into the merge tool.
+		silent, noStream := true, true
+		promptContext := squashPromptContext(*userContext, commitList)
+		message, err := s.aiService.GenerateCommitMessage(providers, ctx, data, &service.CommitOptions{
+			UserContext: &promptContext,
+			Model:       model,
+			Quiet:       &silent,
+			NoStream:    &noStream,
+			MaxLength:   maxLength,
+			Language:    language,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(trailers.Apply(message))
+		return nil
+	}
+
+	if s.gitService.HasStagedChanges() {
+		return service.Errorf(service.ErrCodeInvalidArgument, "the index has staged changes; commit or unstage them before squashing")
+	}
+
+	// Only the user's own context is editable; the commit list is appended
+	// to it for each request.
+	editableContext := *userContext
+	noStream := true
+	opts := &service.CommitOptions{
+		UserContext: &editableContext,
+		Model:       model,
+		NoConfirm:   noConfirm,
+		Quiet:       quiet,
+		DryRun:      dryRun,
+		MaxLength:   maxLength,
+		Language:    language,
+		NoVerify:    noVerify,
+		NoStream:    &noStream,
+	}
+	if !*quiet {
+		color.New(color.Underline).Printf("Squashing %d commits since %s\n", len(commits), baseRef)
+	}
+
+	for {
+		promptContext := squashPromptContext(editableContext, commitList)
+		genOpts := *opts
+		genOpts.UserContext = &promptContext
+		message, err := s.aiService.GenerateCommitMessage(providers, ctx, data, &genOpts)
+		if err != nil {
+			return err
+		}
+
+		selectedAction, finalMessage, err := s.interactionService.HandleUserAction(trailers.Apply(message), opts)
+		if err != nil {
+			return err
+		}
+
+		switch selectedAction {
+		case service.ActionConfirm:
+			return s.squash(mergeBase, head, finalMessage, opts)
+		case service.ActionRegenerate, service.ActionEditContext:
+			continue
+		case service.ActionCancel:
+			color.New(color.FgRed).Println("Squash cancelled")
+			return nil
+		}
+	}
+}
+
+// squashPromptContext puts the user's context, if any, before the list of
+// squashed commits.
+func squashPromptContext(userContext, commitList string) string {
+	if userContext == "" {
+		return commitList
+	}
+	return userContext + "\n\n" + commitList
+}
+
+// squash replaces the commits after mergeBase with a single commit.
+func (s *SquashUsecase) squash(mergeBase, head, message string, opts *service.CommitOptions) error {
+	if *opts.DryRun {
+		if !*opts.Quiet {
+			color.New(color.FgYellow).Println("🔍 DRY RUN - No changes will be made")
+			color.New(color.FgCyan).Printf("Would squash into: %s\n", message)
+		}
+		return nil
+	}
+
+	if err := s.gitService.SoftReset(mergeBase); err != nil {
+		return err
+	}
+	if err := s.gitService.CommitChangesWithOptions(message, opts.Quiet, opts.NoVerify); err != nil {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "To restore the original commits, run: git reset --soft %s\n", head)
+		return err
+	}
+
+	if !*opts.Quiet {
+		color.New(color.FgGreen).Println("✔ Successfully squashed!")
+		fmt.Printf("To undo, run: git reset --keep %s\n", head)
+	}
+	return nil
+}